@@ -2,25 +2,57 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
 	"time"
 	"tritontube/internal/proto"
+	"tritontube/internal/tlsconfig"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
-	if len(os.Args) < 3 { // Minimum 3 args: program, command, server_address
+	tlsConfig := tlsconfig.RegisterFlags(flag.CommandLine)
+	token := flag.String("token", os.Getenv("TRITONTUBE_ADMIN_TOKEN"), "Bearer token for the admin service (defaults to $TRITONTUBE_ADMIN_TOKEN)")
+	insecureToken := flag.Bool("insecure", false, "Send -token without TLS, for development servers only")
+	flag.Usage = printUsageAndExit
+	flag.Parse()
+	args := flag.Args()
+
+	if len(args) >= 1 && args[0] == "certs" {
+		if len(args) < 2 {
+			fmt.Println("Usage: certs <dir> [host...]")
+			os.Exit(1)
+		}
+		generateCerts(args[1], args[2:])
+		return
+	}
+	if len(args) < 2 { // Minimum 2 args: command, server_address
 		printUsageAndExit()
 	}
 
-	cmd := os.Args[1]
-	serverAddr := os.Args[2]
+	cmd := args[0]
+	serverAddr := args[1]
 
-	conn, err := grpc.NewClient(serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if *token != "" && !tlsConfig.Enabled() && !*insecureToken {
+		log.Fatalf("Refusing to send -token without TLS; set -tls-cert, -tls-key and -tls-ca, or -insecure for a development server")
+	}
+	creds, err := tlsConfig.ClientCredentials()
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if *token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(bearerToken{token: *token, insecure: *insecureToken}))
+	}
+	conn, err := grpc.NewClient(serverAddr, dialOpts...)
 	if err != nil {
 		log.Fatalf("Failed to connect to server: %v", err)
 	}
@@ -30,23 +62,72 @@ func main() {
 
 	switch cmd {
 	case "add":
-		if len(os.Args) != 4 {
+		if len(args) != 3 {
 			fmt.Println("Usage: add <server_address> <node_address>")
 			os.Exit(1)
 		}
-		addNode(client, os.Args[3])
+		addNode(client, args[2])
 	case "remove":
-		if len(os.Args) != 4 {
+		if len(args) != 3 {
 			fmt.Println("Usage: remove <server_address> <node_address>")
 			os.Exit(1)
 		}
-		removeNode(client, os.Args[3])
+		removeNode(client, args[2])
 	case "list":
-		if len(os.Args) != 3 {
+		if len(args) != 2 {
 			fmt.Println("Usage: list <server_address>")
 			os.Exit(1)
 		}
 		listNodes(client)
+	case "drain":
+		if len(args) != 3 {
+			fmt.Println("Usage: drain <server_address> <node_address>")
+			os.Exit(1)
+		}
+		drainNode(client, args[2])
+	case "undrain":
+		if len(args) != 3 {
+			fmt.Println("Usage: undrain <server_address> <node_address>")
+			os.Exit(1)
+		}
+		undrainNode(client, args[2])
+	case "decommission":
+		if len(args) != 3 {
+			fmt.Println("Usage: decommission <server_address> <node_address>")
+			os.Exit(1)
+		}
+		decommissionNode(client, args[2])
+	case "plan":
+		if len(args) != 4 || (args[2] != "add" && args[2] != "remove" && args[2] != "drain") {
+			fmt.Println("Usage: plan <server_address> add|remove|drain <node_address>")
+			os.Exit(1)
+		}
+		planMembershipChange(client, args[2], args[3])
+	case "ops":
+		if len(args) != 2 {
+			fmt.Println("Usage: ops <server_address>")
+			os.Exit(1)
+		}
+		listRebalances(client)
+	case "status", "watch", "cancel", "resume":
+		if len(args) != 3 {
+			fmt.Printf("Usage: %s <server_address> <operation_id>\n", cmd)
+			os.Exit(1)
+		}
+		rebalanceCommand(client, cmd, args[2])
+	case "gc":
+		if len(args) < 2 || len(args) > 3 || (len(args) == 3 && args[2] != "delete") {
+			fmt.Println("Usage: gc <server_address> [delete]")
+			os.Exit(1)
+		}
+		collectGarbage(client, len(args) == 3)
+	case "fsck":
+		repair := len(args) > 2 && args[2] == "repair"
+		videoIds := args[2:]
+		if repair {
+			videoIds = args[3:]
+		}
+		checkConsistency(client, repair, videoIds)
 	default:
 		fmt.Printf("Unknown command: %s\n", cmd)
 		printUsageAndExit()
@@ -54,13 +135,62 @@ func main() {
 }
 
 func printUsageAndExit() {
-	fmt.Println("Usage:")
+	fmt.Println("Usage: admin [OPTIONS] <command> ...")
+	fmt.Println()
+	fmt.Println("Commands:")
 	fmt.Println("  add <server_address> <node_address>     - Add a node to the cluster")
 	fmt.Println("  remove <server_address> <node_address>  - Remove a node from the cluster")
 	fmt.Println("  list <server_address>                   - List all nodes in the cluster")
+	fmt.Println("  drain <server_address> <node_address>   - Copy a node's files away while it stays readable")
+	fmt.Println("  undrain <server_address> <node_address> - Return a draining or drained node to service")
+	fmt.Println("  decommission <server_address> <node_address>")
+	fmt.Println("                                          - Remove a drained node after checking its copies")
+	fmt.Println("  plan <server_address> add|remove|drain <node_address>")
+	fmt.Println("                                          - Show what adding or removing a node would move")
+	fmt.Println("  ops <server_address>                    - List rebalance operations")
+	fmt.Println("  status <server_address> <operation_id>  - Show the progress of a rebalance")
+	fmt.Println("  watch <server_address> <operation_id>   - Follow a rebalance until it finishes")
+	fmt.Println("  cancel <server_address> <operation_id>  - Cancel a running rebalance")
+	fmt.Println("  resume <server_address> <operation_id>  - Resume a cancelled or failed rebalance")
+	fmt.Println("  gc <server_address> [delete]            - List files no video refers to, deleting them if asked")
+	fmt.Println("  fsck <server_address> [repair] [video_id...]")
+	fmt.Println("                                          - Check that every segment of each video is intact, repairing if asked")
+	fmt.Println("  certs <dir> [host...]                   - Create a development CA and node certificate")
+	fmt.Println()
+	fmt.Println("Options:")
+	flag.PrintDefaults()
 	os.Exit(1)
 }
 
+// bearerToken sends an admin token with every RPC.
+type bearerToken struct {
+	token    string
+	insecure bool // -insecure allows sending the token in the clear
+}
+
+func (t bearerToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+// RequireTransportSecurity keeps gRPC from sending the token over a
+// connection without TLS unless -insecure was given.
+func (t bearerToken) RequireTransportSecurity() bool {
+	return !t.insecure
+}
+
+// generateCerts writes a development CA and a node certificate for hosts to dir.
+func generateCerts(dir string, hosts []string) {
+	if len(hosts) == 0 {
+		hosts = []string{"localhost", "127.0.0.1"}
+	}
+	if err := tlsconfig.GenerateDevCA(dir, hosts); err != nil {
+		log.Fatalf("Failed to generate certificates: %v", err)
+	}
+	fmt.Printf("Wrote ca.pem, ca-key.pem, cert.pem and key.pem to %s for %s\n", dir, strings.Join(hosts, ", "))
+	fmt.Printf("Use -tls-cert %s -tls-key %s -tls-ca %s with web, storage and admin\n",
+		filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem"))
+}
+
 func addNode(client proto.VideoContentAdminServiceClient, nodeAddr string) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
@@ -72,8 +202,13 @@ func addNode(client proto.VideoContentAdminServiceClient, nodeAddr string) {
 		log.Fatalf("AddNode RPC failed: %v", err)
 	}
 
-	fmt.Printf("Successfully added node: %s\n", nodeAddr)
-	fmt.Printf("Number of files migrated: %d\n", response.MigratedFileCount)
+	fmt.Printf("Started rebalance: %s\n", response.OperationId)
+	st := watchRebalance(client, response.OperationId)
+	if st != nil && st.State == "completed" {
+		fmt.Printf("Successfully added node: %s\n", nodeAddr)
+		fmt.Printf("Number of files migrated: %d\n", st.GetMovedFiles())
+	}
+	exitUnlessCompleted(st)
 }
 
 func removeNode(client proto.VideoContentAdminServiceClient, nodeAddr string) {
@@ -87,25 +222,320 @@ func removeNode(client proto.VideoContentAdminServiceClient, nodeAddr string) {
 		log.Fatalf("RemoveNode RPC failed: %v", err)
 	}
 
-	fmt.Printf("Successfully removed node: %s\n", nodeAddr)
-	fmt.Printf("Number of files migrated: %d\n", response.MigratedFileCount)
+	fmt.Printf("Started rebalance: %s\n", response.OperationId)
+	st := watchRebalance(client, response.OperationId)
+	if st != nil && st.State == "completed" {
+		fmt.Printf("Successfully removed node: %s\n", nodeAddr)
+		fmt.Printf("Number of files migrated: %d\n", st.GetMovedFiles())
+	}
+	exitUnlessCompleted(st)
 }
 
 func listNodes(client proto.VideoContentAdminServiceClient) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	response, err := client.ListNodes(ctx, &proto.ListNodesRequest{})
+	response, err := client.ListNodes(ctx, &proto.ListNodesRequest{IncludeStats: true})
 	if err != nil {
 		log.Fatalf("ListNodes RPC failed: %v", err)
 	}
 
 	fmt.Println("Storage cluster nodes:")
-	if len(response.Nodes) == 0 {
+	if len(response.Statuses) == 0 {
 		fmt.Println("  No nodes in cluster")
-	} else {
-		for _, node := range response.Nodes {
-			fmt.Printf("  - %s\n", node)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NODE\tSTATE\tFILES\tUSED\tON DISK\tDEDUP\tFREE\tREADS\tWRITES\tERRORS\tCORRUPT")
+	var corrupt []string
+	for _, node := range response.Statuses {
+		st := node.Stats
+		if st == nil {
+			fmt.Fprintf(w, "  %s\t%s\t-\t-\t-\t-\t-\t-\t-\t-\t(%s)\n", node.Address, node.State, node.StatsError)
+			continue
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%d\t%s\t%s\t%.2fx\t%s\t%d\t%d\t%d\t%d\n", node.Address, node.State,
+			st.FileCount, formatBytes(st.BytesUsed), formatBytes(st.StoredBytes), st.DedupRatio, formatBytes(st.FreeBytes),
+			st.Requests.GetReads(), st.Requests.GetWrites(), st.Requests.GetErrors(), len(st.CorruptFiles))
+		for _, path := range st.CorruptFiles {
+			corrupt = append(corrupt, fmt.Sprintf("  %s on %s", path, node.Address))
 		}
 	}
+	w.Flush()
+	if len(corrupt) > 0 {
+		fmt.Println("Corrupt files needing repair:")
+		fmt.Println(strings.Join(corrupt, "\n"))
+	}
+
+	if u := response.Usage; u != nil {
+		fmt.Printf("Cluster: %d files, %s used (%s on disk, %.2fx deduplication), %s free of %s\n",
+			u.FileCount, formatBytes(u.BytesUsed), formatBytes(u.StoredBytes), u.DedupRatio, formatBytes(u.FreeBytes), formatBytes(u.TotalBytes))
+		if len(u.TopVideos) > 0 {
+			fmt.Println("Largest videos:")
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, v := range u.TopVideos {
+				fmt.Fprintf(w, "  %s\t%d files\t%s\n", v.VideoId, v.Files, formatBytes(v.Bytes))
+			}
+			w.Flush()
+		}
+	}
+}
+
+// formatBytes renders n with a binary unit suffix.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func drainNode(client proto.VideoContentAdminServiceClient, nodeAddr string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	response, err := client.DrainNode(ctx, &proto.DrainNodeRequest{
+		NodeAddress: nodeAddr,
+	})
+	if err != nil {
+		log.Fatalf("DrainNode RPC failed: %v", err)
+	}
+
+	fmt.Printf("Started drain: %s\n", response.OperationId)
+	st := watchRebalance(client, response.OperationId)
+	if st != nil && st.State == "completed" {
+		fmt.Printf("Node %s is drained; run decommission to remove it\n", nodeAddr)
+	}
+	exitUnlessCompleted(st)
+}
+
+func undrainNode(client proto.VideoContentAdminServiceClient, nodeAddr string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	response, err := client.UndrainNode(ctx, &proto.UndrainNodeRequest{
+		NodeAddress: nodeAddr,
+	})
+	if err != nil {
+		log.Fatalf("UndrainNode RPC failed: %v", err)
+	}
+
+	fmt.Printf("Started rebalance: %s\n", response.OperationId)
+	st := watchRebalance(client, response.OperationId)
+	if st != nil && st.State == "completed" {
+		fmt.Printf("Node %s is back in service\n", nodeAddr)
+		fmt.Printf("Number of files migrated: %d\n", st.GetMovedFiles())
+	}
+	exitUnlessCompleted(st)
+}
+
+func decommissionNode(client proto.VideoContentAdminServiceClient, nodeAddr string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	response, err := client.DecommissionNode(ctx, &proto.DecommissionNodeRequest{
+		NodeAddress: nodeAddr,
+	})
+	if err != nil {
+		log.Fatalf("DecommissionNode RPC failed: %v", err)
+	}
+
+	fmt.Printf("Successfully decommissioned node: %s\n", nodeAddr)
+	fmt.Printf("Number of files verified: %d\n", response.VerifiedFiles)
+}
+
+func planMembershipChange(client proto.VideoContentAdminServiceClient, action string, nodeAddr string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	response, err := client.PlanMembershipChange(ctx, &proto.PlanMembershipChangeRequest{
+		Action:      action,
+		NodeAddress: nodeAddr,
+	})
+	if err != nil {
+		log.Fatalf("PlanMembershipChange RPC failed: %v", err)
+	}
+
+	fmt.Printf("Plan to %s node %s (dry run)\n", action, nodeAddr)
+	fmt.Printf("Nodes after change: %s\n", strings.Join(response.Nodes, ", "))
+	if len(response.Transfers) == 0 {
+		fmt.Println("No files would move")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  SOURCE\tTARGET\tFILES\tBYTES")
+	for _, t := range response.Transfers {
+		fmt.Fprintf(w, "  %s\t%s\t%d\t%d\n", t.Source, t.Target, t.FileCount, t.Bytes)
+	}
+	w.Flush()
+	fmt.Printf("Total: %d files, %d bytes would move\n", response.TotalFiles, response.TotalBytes)
+}
+
+func collectGarbage(client proto.VideoContentAdminServiceClient, del bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	response, err := client.CollectGarbage(ctx, &proto.CollectGarbageRequest{DryRun: !del})
+	if err != nil {
+		log.Fatalf("CollectGarbage RPC failed: %v", err)
+	}
+
+	fmt.Printf("Scanned %d files, %d orphaned\n", response.ScannedFiles, len(response.Orphans))
+	if len(response.Orphans) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  NODE\tPATH\tSIZE\tWRITTEN")
+		for _, o := range response.Orphans {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", o.NodeAddress, o.Path, formatBytes(o.Size),
+				time.Unix(o.ModTime, 0).Format(time.RFC3339))
+		}
+		w.Flush()
+	}
+	for _, e := range response.Errors {
+		fmt.Printf("Error: %s\n", e)
+	}
+	if !del {
+		if len(response.Orphans) > 0 {
+			fmt.Println("Dry run; run again with delete to remove them")
+		}
+		return
+	}
+	fmt.Printf("Deleted %d files (%s)\n", response.DeletedFiles, formatBytes(response.DeletedBytes))
+	if len(response.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+func checkConsistency(client proto.VideoContentAdminServiceClient, repair bool, videoIds []string) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	stream, err := client.CheckConsistency(ctx, &proto.CheckConsistencyRequest{VideoIds: videoIds, Repair: repair})
+	if err != nil {
+		log.Fatalf("CheckConsistency RPC failed: %v", err)
+	}
+	var checked, unhealthy int
+	for {
+		h, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("CheckConsistency RPC failed: %v", err)
+		}
+		checked++
+		switch {
+		case h.Error != "":
+			fmt.Printf("%s: BROKEN (%s)\n", h.VideoId, h.Error)
+		case !h.Healthy:
+			fmt.Printf("%s: BROKEN (%d of %d files have problems)\n", h.VideoId, len(h.Problems), h.Files)
+		case len(h.Problems) > 0:
+			fmt.Printf("%s: REPAIRED (%d of %d files restored)\n", h.VideoId, len(h.Problems), h.Files)
+		default:
+			fmt.Printf("%s: OK (%d files)\n", h.VideoId, h.Files)
+		}
+		if !h.Healthy {
+			unhealthy++
+		}
+		for _, p := range h.Problems {
+			if p.Repaired {
+				fmt.Printf("  %s on %s: %s, restored from %s\n", p.Filename, p.NodeAddress, p.Problem, p.RepairedFrom)
+			} else {
+				fmt.Printf("  %s on %s: %s\n", p.Filename, p.NodeAddress, p.Problem)
+			}
+		}
+	}
+	fmt.Printf("Checked %d videos, %d unhealthy\n", checked, unhealthy)
+	if unhealthy > 0 {
+		os.Exit(1)
+	}
+}
+
+func listRebalances(client proto.VideoContentAdminServiceClient) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	response, err := client.ListRebalances(ctx, &proto.ListRebalancesRequest{})
+	if err != nil {
+		log.Fatalf("ListRebalances RPC failed: %v", err)
+	}
+
+	fmt.Println("Rebalance operations:")
+	if len(response.Operations) == 0 {
+		fmt.Println("  No rebalance operations")
+	}
+	for _, st := range response.Operations {
+		fmt.Printf("  - %s\n", formatRebalance(st))
+	}
+}
+
+func rebalanceCommand(client proto.VideoContentAdminServiceClient, cmd string, opID string) {
+	if cmd == "watch" {
+		st := watchRebalance(client, opID)
+		exitUnlessCompleted(st)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req := &proto.GetRebalanceRequest{OperationId: opID}
+	var st *proto.RebalanceStatus
+	var err error
+	switch cmd {
+	case "status":
+		st, err = client.GetRebalance(ctx, req)
+	case "cancel":
+		st, err = client.CancelRebalance(ctx, req)
+	case "resume":
+		st, err = client.ResumeRebalance(ctx, req)
+	}
+	if err != nil {
+		log.Fatalf("%s failed: %v", cmd, err)
+	}
+	fmt.Println(formatRebalance(st))
+}
+
+// watchRebalance prints progress until the operation finishes and returns its
+// final status. Interrupting the watch leaves the operation running.
+func watchRebalance(client proto.VideoContentAdminServiceClient, opID string) *proto.RebalanceStatus {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	stream, err := client.WatchRebalance(ctx, &proto.GetRebalanceRequest{OperationId: opID})
+	if err != nil {
+		log.Fatalf("WatchRebalance RPC failed: %v", err)
+	}
+	var last *proto.RebalanceStatus
+	for {
+		st, err := stream.Recv()
+		if err == io.EOF {
+			return last
+		}
+		if err != nil {
+			log.Fatalf("WatchRebalance RPC failed: %v (operation %s keeps running)", err, opID)
+		}
+		fmt.Println(formatRebalance(st))
+		last = st
+	}
+}
+
+func formatRebalance(st *proto.RebalanceStatus) string {
+	line := fmt.Sprintf("%s %s %s: %s, %d/%d files, %d/%d bytes moved, %d failed",
+		st.OperationId, st.Kind, st.NodeAddress, st.State,
+		st.MovedFiles, st.TotalFiles, st.MovedBytes, st.TotalBytes, st.FailedFiles)
+	if st.Error != "" {
+		line += " (" + st.Error + ")"
+	}
+	return line
+}
+
+func exitUnlessCompleted(st *proto.RebalanceStatus) {
+	if st == nil || st.State != "completed" {
+		os.Exit(1)
+	}
 }
@@ -1,20 +1,31 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"net"
+	"strings"
+	"time"
 
 	"tritontube/internal/proto"
 	"tritontube/internal/storage"
+	"tritontube/internal/tlsconfig"
 
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"google.golang.org/grpc"
 )
 
 func main() {
 	host := flag.String("host", "localhost", "Host address for the server")
 	port := flag.Int("port", 8090, "Port number for the server")
+	etcdEndpoints := flag.String("etcd", "", "Comma-separated etcd endpoints to register this node with (no registration if empty)")
+	registryPrefix := flag.String("registry-prefix", "/tritontube/storage/", "etcd key prefix for node registration")
+	advertise := flag.String("advertise", "", "Address to register in etcd (defaults to host:port)")
+	leaseTTL := flag.Int64("lease-ttl", 10, "Registration lease TTL in seconds")
+	scrubInterval := flag.Duration("scrub-interval", 24*time.Hour, "How often to re-hash stored files looking for corruption (0 disables)")
+	tlsConfig := tlsconfig.RegisterFlags(flag.CommandLine)
 	flag.Parse()
 
 	if *port <= 0 {
@@ -33,7 +44,16 @@ func main() {
 	fmt.Printf("Port: %d\n", *port)
 	fmt.Printf("Base Directory: %s\n", baseDir)
 
-	srv, err := storage.NewStorageServer(baseDir)
+	serverCreds, err := tlsConfig.ServerCredentials()
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+	peerCreds, err := tlsConfig.ClientCredentials()
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+
+	srv, err := storage.NewStorageServer(baseDir, storage.WithPeerCredentials(peerCreds))
 	if err != nil {
 		log.Fatalf("Failed to create storage server: %v", err)
 	}
@@ -44,9 +64,31 @@ func main() {
 		log.Fatalf("Failed to listen: %v", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.Creds(serverCreds))
 	proto.RegisterVideoStorageServiceServer(grpcServer, srv)
 
+	if *etcdEndpoints != "" {
+		cli, err := clientv3.New(clientv3.Config{
+			Endpoints:   strings.Split(*etcdEndpoints, ","),
+			DialTimeout: 5 * time.Second,
+		})
+		if err != nil {
+			log.Fatalf("Failed to connect to etcd: %v", err)
+		}
+		defer cli.Close()
+		addr := *advertise
+		if addr == "" {
+			addr = listenAddr
+		}
+		if err := storage.Register(context.Background(), cli, *registryPrefix, addr, *leaseTTL); err != nil {
+			log.Fatalf("Failed to register with etcd: %v", err)
+		}
+	}
+
+	if *scrubInterval > 0 {
+		go srv.Scrub(context.Background(), *scrubInterval)
+	}
+
 	log.Printf("Storage server listening on %s", listenAddr)
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("gRPC server error: %v", err)
@@ -6,7 +6,9 @@ import (
 	"io"
 	"log"
 	"net"
+	"os"
 	"strings"
+	"tritontube/internal/tlsconfig"
 	"tritontube/internal/web"
 )
 
@@ -17,8 +19,8 @@ func printUsage() {
 	fmt.Println("Arguments:")
 	fmt.Println("  METADATA_TYPE         Metadata service type (sqlite, etcd)")
 	fmt.Println("  METADATA_OPTIONS      Options for metadata service (e.g., db path)")
-	fmt.Println("  CONTENT_TYPE          Content service type (fs, nw)")
-	fmt.Println("  CONTENT_OPTIONS       Options for content service (e.g., base dir, network addresses)")
+	fmt.Println("  CONTENT_TYPE          Content service type (fs, nw, s3)")
+	fmt.Println("  CONTENT_OPTIONS       Options for content service (e.g., base dir, network addresses, bucket[/prefix])")
 	fmt.Println()
 	fmt.Println("Options:")
 	flag.PrintDefaults()
@@ -30,6 +32,40 @@ func main() {
 	// Define flags
 	port := flag.Int("port", 8080, "Port number for the web server")
 	host := flag.String("host", "localhost", "Host address for the web server")
+	shardKey := flag.String("shard-key", "file", "Placement key for nw content: file, video or segment")
+	segmentBucket := flag.Int("segment-bucket", web.DefaultSegmentBucketSize, "Segments per placement bucket when -shard-key=segment")
+	erasureData := flag.Int("erasure-data", 0, "Data shards per nw file for erasure coding (0 stores a single copy)")
+	erasureParity := flag.Int("erasure-parity", 2, "Parity shards per nw file when -erasure-data is set")
+	erasureScan := flag.Duration("erasure-scan-interval", web.DefaultShardScanInterval, "How often the cluster leader looks for nw files with lost shards and rebuilds them (0 disables)")
+	stateDB := flag.String("state-db", "", "SQLite file for nw cluster membership and rebalance progress (in memory if empty)")
+	joinSeeds := flag.Bool("join-seeds", true, "Add nw seed nodes missing from the persisted membership with a rebalance; if false the persisted membership wins and they are ignored")
+	rebalanceWorkers := flag.Int("rebalance-workers", 4, "Files moved in parallel during a rebalance")
+	rebalanceRate := flag.Int64("rebalance-rate", 0, "Rebalance bandwidth limit in bytes per second (0 for unlimited)")
+	registryEtcd := flag.String("registry-etcd", "", "Comma-separated etcd endpoints to watch for self-registered storage nodes (disabled if empty)")
+	registryPrefix := flag.String("registry-prefix", "/tritontube/storage/", "etcd key prefix storage nodes register under")
+	retryAttempts := flag.Int("retry-attempts", web.DefaultRetryPolicy.MaxAttempts, "Attempts per storage read or write, including the first")
+	retryBackoff := flag.Duration("retry-backoff", web.DefaultRetryPolicy.InitialBackoff, "Backoff before the first storage retry, doubled on each further retry")
+	retryMaxBackoff := flag.Duration("retry-max-backoff", web.DefaultRetryPolicy.MaxBackoff, "Upper bound on the storage retry backoff")
+	hedgeAfter := flag.Duration("hedge-after", 0, "Send a second read to another node when a read takes longer than this (0 disables)")
+	tlsConfig := tlsconfig.RegisterFlags(flag.CommandLine)
+	adminAuthFile := flag.String("admin-auth", "", "JSON file mapping admin tokens and certificate names to roles (no authentication if empty)")
+	insecureTokens := flag.Bool("insecure", false, "Accept -admin-auth tokens without TLS, for development only")
+	auditLog := flag.String("admin-audit-log", "", "File to append admin audit entries to (standard log if empty)")
+	clusterEtcd := flag.String("cluster-etcd", "", "Comma-separated etcd endpoints for membership shared by all web frontends (local state if empty)")
+	clusterPrefix := flag.String("cluster-prefix", "/tritontube/cluster/", "etcd key prefix for shared membership and leader election")
+	advertiseAdmin := flag.String("advertise-admin", "", "Admin address other frontends forward to when this one leads (defaults to the admin address)")
+	gcInterval := flag.Duration("gc-interval", 0, "How often the cluster leader deletes nw content no video refers to (0 disables; admin gc still works)")
+	sharedMetadata := flag.Bool("shared-metadata", false, "Every -cluster-etcd frontend uses the same metadata store, so garbage collection may delete content no video refers to")
+	gcGrace := flag.Duration("gc-grace", web.DefaultGCGrace, "Minimum age of unreferenced nw content before garbage collection deletes it")
+	s3Endpoint := flag.String("s3-endpoint", "", "S3 compatible endpoint URL for s3 content (AWS if empty)")
+	s3Region := flag.String("s3-region", "", "Region of the s3 content bucket (from the AWS configuration if empty)")
+	s3PathStyle := flag.Bool("s3-path-style", false, "Address the s3 content bucket as endpoint/bucket, as MinIO and most other S3 servers expect")
+	s3PartSize := flag.Int64("s3-part-size", web.DefaultS3PartSize, "Part size for multipart uploads of s3 content; larger files are uploaded in parts")
+	cacheMemory := flag.Int64("cache-memory", 0, "Bytes of content to cache in memory (0 disables the cache)")
+	cacheDir := flag.String("cache-dir", "", "Directory for a second, on-disk content cache tier (none if empty)")
+	cacheDisk := flag.Int64("cache-disk", 1<<30, "Bytes of content to cache in -cache-dir")
+	cachePolicy := flag.String("cache-policy", string(web.CacheLRU), "Content cache eviction policy: lru or lfu")
+	cacheTTL := flag.Duration("cache-ttl", web.DefaultCacheTTL, "How long cached content is served before it is read again, bounding how stale it gets when other frontends change it (0 never expires it)")
 
 	// Set custom usage message
 	flag.Usage = printUsage
@@ -95,19 +131,96 @@ func main() {
 		// For Lab 8 - not implemented in Lab 7
 		// log.Fatalf("Unsupported content service type: %s (only 'fs' is supported in Lab 7)", contentServiceType)
 		parts := strings.Split(contentServiceOptions, ",")
-		if len(parts) < 2 {
+		if len(parts) < 2 && *registryEtcd == "" && *clusterEtcd == "" {
 			log.Fatalf("invalid content options for nw: %s", contentServiceOptions)
 		}
 		adminAddr := parts[0]
 		nodeAddrs := parts[1:]
-		contentService, err = web.NewNetworkVideoContentService(adminAddr, nodeAddrs)
+		key, err := web.ParseShardingKey(*shardKey)
+		if err != nil {
+			log.Fatalf("Invalid -shard-key: %v", err)
+		}
+		opts := []web.NetworkOption{
+			web.WithShardingKey(key, *segmentBucket),
+			web.WithRebalanceWorkers(*rebalanceWorkers),
+			web.WithRebalanceRateLimit(*rebalanceRate),
+			web.WithRetryPolicy(web.RetryPolicy{
+				MaxAttempts:    *retryAttempts,
+				InitialBackoff: *retryBackoff,
+				MaxBackoff:     *retryMaxBackoff,
+				Multiplier:     web.DefaultRetryPolicy.Multiplier,
+				Jitter:         web.DefaultRetryPolicy.Jitter,
+			}),
+			web.WithHedgedReads(*hedgeAfter),
+			web.WithTLS(tlsConfig),
+			web.WithMetadataService(metadataService),
+			web.WithSharedMetadata(*sharedMetadata),
+			web.WithGarbageCollection(*gcGrace, *gcInterval),
+			web.WithErasureCoding(*erasureData, *erasureParity),
+			web.WithShardScanInterval(*erasureScan),
+			web.WithJoinSeeds(*joinSeeds),
+		}
+		if *stateDB != "" {
+			opts = append(opts, web.WithStateDB(*stateDB))
+		}
+		if *adminAuthFile != "" {
+			auth, err := web.LoadAdminAuth(*adminAuthFile)
+			if err != nil {
+				log.Fatalf("Failed to load -admin-auth: %v", err)
+			}
+			if len(auth.Tokens) > 0 && !tlsConfig.Enabled() && !*insecureTokens {
+				log.Fatalf("Refusing to accept -admin-auth tokens without TLS; set -tls-cert, -tls-key and -tls-ca, or -insecure for development")
+			}
+			opts = append(opts, web.WithAdminAuth(auth))
+		}
+		if *auditLog != "" {
+			f, err := os.OpenFile(*auditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				log.Fatalf("Failed to open -admin-audit-log: %v", err)
+			}
+			defer f.Close()
+			opts = append(opts, web.WithAuditLog(f))
+		}
+		if *clusterEtcd != "" {
+			opts = append(opts, web.WithSharedCluster(strings.Split(*clusterEtcd, ","), *clusterPrefix, *advertiseAdmin))
+		}
+		if *registryEtcd != "" {
+			opts = append(opts, web.WithNodeRegistry(strings.Split(*registryEtcd, ","), *registryPrefix))
+		}
+		contentService, err = web.NewNetworkVideoContentService(adminAddr, nodeAddrs, opts...)
 		if err != nil {
 			log.Fatalf("Failed to create network content service: %v", err)
 		}
+	case "s3":
+		bucket, prefix, _ := strings.Cut(contentServiceOptions, "/")
+		contentService, err = web.NewS3VideoContentService(bucket, prefix,
+			web.WithS3Endpoint(*s3Endpoint),
+			web.WithS3Region(*s3Region),
+			web.WithS3PathStyle(*s3PathStyle),
+			web.WithS3PartSize(*s3PartSize),
+		)
+		if err != nil {
+			log.Fatalf("Failed to create S3 content service: %v", err)
+		}
 	default:
 		log.Fatalf("Unknown content service type: %s", contentServiceType)
 	}
 
+	if *cacheMemory > 0 || *cacheDir != "" {
+		policy, err := web.ParseCachePolicy(*cachePolicy)
+		if err != nil {
+			log.Fatalf("Invalid -cache-policy: %v", err)
+		}
+		opts := []web.CacheOption{web.WithCachePolicy(policy), web.WithCacheTTL(*cacheTTL)}
+		if *cacheDir != "" {
+			opts = append(opts, web.WithDiskCache(*cacheDir, *cacheDisk))
+		}
+		contentService, err = web.NewCachedVideoContentService(contentService, *cacheMemory, opts...)
+		if err != nil {
+			log.Fatalf("Failed to create content cache: %v", err)
+		}
+	}
+
 	// Start the server
 	server := web.NewServer(metadataService, contentService)
 	listenAddr := fmt.Sprintf("%s:%d", *host, *port)
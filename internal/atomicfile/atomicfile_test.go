@@ -0,0 +1,103 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileReplacesContents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.mpd")
+	if err := WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := WriteFile(path, []byte("new"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "new" {
+		t.Errorf("contents = %q, want %q", got, "new")
+	}
+	assertNoTempFiles(t, filepath.Dir(path))
+}
+
+func TestLinkReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	target, path := filepath.Join(dir, "blob"), filepath.Join(dir, "file")
+	if err := os.WriteFile(target, []byte("blob"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Link(target, path); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	// Linking again to the same file must not leave the temporary link behind.
+	if err := Link(target, path); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	ti, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(ti, pi) {
+		t.Errorf("%s is not a link to %s", path, target)
+	}
+	assertNoTempFiles(t, dir)
+}
+
+func TestSweepRemovesInterruptedWrites(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "video")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "manifest.mpd")
+	if err := WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	// A crash after the temporary file was created but before the rename
+	// leaves it partly written next to the old file.
+	if err := os.WriteFile(filepath.Join(dir, tempPrefix+"manifest.mpd-123"), []byte("ne"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A crash inside Link leaves a temporary link to the target.
+	if err := os.Link(path, filepath.Join(dir, tempPrefix+"copy.mpd-456")); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := Sweep(root)
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Sweep removed %d files, want 2", n)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "old" {
+		t.Errorf("contents = %q, want %q", got, "old")
+	}
+	assertNoTempFiles(t, dir)
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, tempPrefix+"*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) > 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
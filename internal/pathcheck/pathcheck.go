@@ -0,0 +1,85 @@
+// Package pathcheck validates the video IDs and filenames that the web and
+// storage servers turn into paths, so that no request can reach outside a
+// base directory.
+package pathcheck
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInvalid is wrapped by every validation error.
+var ErrInvalid = errors.New("invalid name")
+
+// maxLength is the longest name accepted, in bytes, matching the common
+// filesystem limit for one path component.
+const maxLength = 255
+
+// reservedNames are device names that Windows will not create as files.
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// ValidateVideoID checks that id is safe to use as a directory name.
+func ValidateVideoID(id string) error {
+	if err := check(id); err != nil {
+		return fmt.Errorf("%w: video ID %q %s", ErrInvalid, id, err.Error())
+	}
+	return nil
+}
+
+// ValidateFilename checks that name is safe to use as a file name inside a
+// video's directory.
+func ValidateFilename(name string) error {
+	if err := check(name); err != nil {
+		return fmt.Errorf("%w: filename %q %s", ErrInvalid, name, err.Error())
+	}
+	return nil
+}
+
+// Validate checks both parts of a videoId/filename pair.
+func Validate(videoId, filename string) error {
+	if err := ValidateVideoID(videoId); err != nil {
+		return err
+	}
+	return ValidateFilename(filename)
+}
+
+// check returns why name cannot be a single path component, or nil.
+func check(name string) error {
+	switch {
+	case name == "":
+		return errors.New("is empty")
+	case len(name) > maxLength:
+		return fmt.Errorf("is longer than %d bytes", maxLength)
+	case !utf8.ValidString(name):
+		return errors.New("is not valid UTF-8")
+	case strings.ContainsAny(name, `/\`):
+		return errors.New("contains a path separator")
+	case strings.HasPrefix(name, "."):
+		// Covers "." and "..", and the hidden files servers keep beside content.
+		return errors.New("starts with a dot")
+	case strings.HasSuffix(name, " "):
+		return errors.New("ends with a space")
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return errors.New("contains a control character")
+		}
+		if r == ':' {
+			return errors.New("contains a colon")
+		}
+	}
+	base, _, _ := strings.Cut(name, ".")
+	if reservedNames[strings.ToUpper(base)] {
+		return errors.New("is a reserved name")
+	}
+	return nil
+}
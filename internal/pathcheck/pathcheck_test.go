@@ -0,0 +1,97 @@
+package pathcheck
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		videoId  string
+		filename string
+		ok       bool
+	}{
+		{"plain", "my-video", "manifest.mpd", true},
+		{"segment", "a1b2c3", "chunk-stream0-00001.m4s", true},
+		{"unicode", "vidéo", "init-stream0.m4s", true},
+		{"dot dot video", "..", "manifest.mpd", false},
+		{"dot dot filename", "video", "..", false},
+		{"dot", ".", "manifest.mpd", false},
+		{"hidden file", "video", ".catalog.db", false},
+		{"traversal in video", "../etc", "passwd", false},
+		{"traversal in filename", "video", "../../etc/passwd", false},
+		{"absolute video", "/etc", "passwd", false},
+		{"absolute filename", "video", "/etc/passwd", false},
+		{"slash", "a/b", "manifest.mpd", false},
+		{"backslash", "video", `..\..\boot.ini`, false},
+		{"windows drive", "C:", "manifest.mpd", false},
+		{"nul byte", "video", "manifest\x00.mpd", false},
+		{"newline", "vid\neo", "manifest.mpd", false},
+		{"delete char", "video", "manifest\x7f", false},
+		{"empty video", "", "manifest.mpd", false},
+		{"empty filename", "video", "", false},
+		{"reserved", "CON", "manifest.mpd", false},
+		{"reserved lower case", "video", "nul", false},
+		{"reserved with extension", "video", "com1.m4s", false},
+		{"not reserved", "video", "console.mpd", true},
+		{"trailing space", "video ", "manifest.mpd", false},
+		{"invalid utf-8", "vid\xffeo", "manifest.mpd", false},
+		{"too long", strings.Repeat("a", maxLength+1), "manifest.mpd", false},
+		{"longest", strings.Repeat("a", maxLength), "manifest.mpd", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.videoId, tt.filename)
+			if tt.ok && err != nil {
+				t.Errorf("Validate(%q, %q) = %v, want nil", tt.videoId, tt.filename, err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalid) {
+				t.Errorf("Validate(%q, %q) = %v, want ErrInvalid", tt.videoId, tt.filename, err)
+			}
+		})
+	}
+}
+
+// FuzzValidate checks that every accepted pair names a file exactly two levels
+// below the base directory, and that every rejection wraps ErrInvalid.
+func FuzzValidate(f *testing.F) {
+	for _, seed := range [][2]string{
+		{"video", "manifest.mpd"},
+		{"..", "x"},
+		{"video", "../x"},
+		{"/abs", "x"},
+		{"video", `a\b`},
+		{"", ""},
+		{"CON", "aux.txt"},
+		{"video", "x\x00y"},
+	} {
+		f.Add(seed[0], seed[1])
+	}
+	base := filepath.FromSlash("/srv/content")
+	f.Fuzz(func(t *testing.T, videoId, filename string) {
+		err := Validate(videoId, filename)
+		if err != nil {
+			if !errors.Is(err, ErrInvalid) {
+				t.Fatalf("Validate(%q, %q) = %v, which does not wrap ErrInvalid", videoId, filename, err)
+			}
+			return
+		}
+		path := filepath.Join(base, videoId, filename)
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			t.Fatalf("Rel(%q, %q): %v", base, path, err)
+		}
+		if rel != filepath.Join(videoId, filename) {
+			t.Fatalf("Validate accepted %q, %q, which resolves to %q", videoId, filename, path)
+		}
+		if dir, name := filepath.Split(rel); filepath.Clean(dir) != videoId || name != filename {
+			t.Fatalf("Validate accepted %q, %q, which splits into %q, %q", videoId, filename, dir, name)
+		}
+		if strings.ContainsRune(videoId+filename, 0) {
+			t.Fatalf("Validate accepted a NUL byte in %q, %q", videoId, filename)
+		}
+	})
+}
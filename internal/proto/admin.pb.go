@@ -66,8 +66,13 @@ func (x *AddNodeRequest) GetNodeAddress() string {
 }
 
 type AddNodeResponse struct {
-	state             protoimpl.MessageState `protogen:"open.v1"`
-	MigratedFileCount int32                  `protobuf:"varint,1,opt,name=migrated_file_count,json=migratedFileCount,proto3" json:"migrated_file_count,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// migrated_file_count is never set: the response returns before the
+	// rebalance moves anything. Read RebalanceStatus.moved_files instead.
+	//
+	// Deprecated: Marked as deprecated in proto/admin.proto.
+	MigratedFileCount int32  `protobuf:"varint,1,opt,name=migrated_file_count,json=migratedFileCount,proto3" json:"migrated_file_count,omitempty"`
+	OperationId       string `protobuf:"bytes,2,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`
 	unknownFields     protoimpl.UnknownFields
 	sizeCache         protoimpl.SizeCache
 }
@@ -102,6 +107,7 @@ func (*AddNodeResponse) Descriptor() ([]byte, []int) {
 	return file_proto_admin_proto_rawDescGZIP(), []int{1}
 }
 
+// Deprecated: Marked as deprecated in proto/admin.proto.
 func (x *AddNodeResponse) GetMigratedFileCount() int32 {
 	if x != nil {
 		return x.MigratedFileCount
@@ -109,6 +115,13 @@ func (x *AddNodeResponse) GetMigratedFileCount() int32 {
 	return 0
 }
 
+func (x *AddNodeResponse) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
 type RemoveNodeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	NodeAddress   string                 `protobuf:"bytes,1,opt,name=node_address,json=nodeAddress,proto3" json:"node_address,omitempty"`
@@ -154,8 +167,13 @@ func (x *RemoveNodeRequest) GetNodeAddress() string {
 }
 
 type RemoveNodeResponse struct {
-	state             protoimpl.MessageState `protogen:"open.v1"`
-	MigratedFileCount int32                  `protobuf:"varint,1,opt,name=migrated_file_count,json=migratedFileCount,proto3" json:"migrated_file_count,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// migrated_file_count is never set: the response returns before the
+	// rebalance moves anything. Read RebalanceStatus.moved_files instead.
+	//
+	// Deprecated: Marked as deprecated in proto/admin.proto.
+	MigratedFileCount int32  `protobuf:"varint,1,opt,name=migrated_file_count,json=migratedFileCount,proto3" json:"migrated_file_count,omitempty"`
+	OperationId       string `protobuf:"bytes,2,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`
 	unknownFields     protoimpl.UnknownFields
 	sizeCache         protoimpl.SizeCache
 }
@@ -190,6 +208,7 @@ func (*RemoveNodeResponse) Descriptor() ([]byte, []int) {
 	return file_proto_admin_proto_rawDescGZIP(), []int{3}
 }
 
+// Deprecated: Marked as deprecated in proto/admin.proto.
 func (x *RemoveNodeResponse) GetMigratedFileCount() int32 {
 	if x != nil {
 		return x.MigratedFileCount
@@ -197,8 +216,17 @@ func (x *RemoveNodeResponse) GetMigratedFileCount() int32 {
 	return 0
 }
 
+func (x *RemoveNodeResponse) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
 type ListNodesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// include_stats asks every node for its usage.
+	IncludeStats  bool `protobuf:"varint,1,opt,name=include_stats,json=includeStats,proto3" json:"include_stats,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -233,16 +261,187 @@ func (*ListNodesRequest) Descriptor() ([]byte, []int) {
 	return file_proto_admin_proto_rawDescGZIP(), []int{4}
 }
 
+func (x *ListNodesRequest) GetIncludeStats() bool {
+	if x != nil {
+		return x.IncludeStats
+	}
+	return false
+}
+
+type NodeStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	State         string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	Stats         *GetStatsResponse      `protobuf:"bytes,3,opt,name=stats,proto3" json:"stats,omitempty"`
+	StatsError    string                 `protobuf:"bytes,4,opt,name=stats_error,json=statsError,proto3" json:"stats_error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeStatus) Reset() {
+	*x = NodeStatus{}
+	mi := &file_proto_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeStatus) ProtoMessage() {}
+
+func (x *NodeStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeStatus.ProtoReflect.Descriptor instead.
+func (*NodeStatus) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *NodeStatus) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *NodeStatus) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *NodeStatus) GetStats() *GetStatsResponse {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+func (x *NodeStatus) GetStatsError() string {
+	if x != nil {
+		return x.StatsError
+	}
+	return ""
+}
+
+type ClusterUsage struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	FileCount  int64                  `protobuf:"varint,1,opt,name=file_count,json=fileCount,proto3" json:"file_count,omitempty"`
+	BytesUsed  int64                  `protobuf:"varint,2,opt,name=bytes_used,json=bytesUsed,proto3" json:"bytes_used,omitempty"`
+	FreeBytes  int64                  `protobuf:"varint,3,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"`
+	TotalBytes int64                  `protobuf:"varint,4,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	// top_videos are the videos using the most space across all nodes.
+	TopVideos []*VideoUsage `protobuf:"bytes,5,rep,name=top_videos,json=topVideos,proto3" json:"top_videos,omitempty"`
+	// stored_bytes is the space the files take on disk after deduplication.
+	StoredBytes   int64   `protobuf:"varint,6,opt,name=stored_bytes,json=storedBytes,proto3" json:"stored_bytes,omitempty"`
+	DedupRatio    float64 `protobuf:"fixed64,7,opt,name=dedup_ratio,json=dedupRatio,proto3" json:"dedup_ratio,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterUsage) Reset() {
+	*x = ClusterUsage{}
+	mi := &file_proto_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterUsage) ProtoMessage() {}
+
+func (x *ClusterUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterUsage.ProtoReflect.Descriptor instead.
+func (*ClusterUsage) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ClusterUsage) GetFileCount() int64 {
+	if x != nil {
+		return x.FileCount
+	}
+	return 0
+}
+
+func (x *ClusterUsage) GetBytesUsed() int64 {
+	if x != nil {
+		return x.BytesUsed
+	}
+	return 0
+}
+
+func (x *ClusterUsage) GetFreeBytes() int64 {
+	if x != nil {
+		return x.FreeBytes
+	}
+	return 0
+}
+
+func (x *ClusterUsage) GetTotalBytes() int64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *ClusterUsage) GetTopVideos() []*VideoUsage {
+	if x != nil {
+		return x.TopVideos
+	}
+	return nil
+}
+
+func (x *ClusterUsage) GetStoredBytes() int64 {
+	if x != nil {
+		return x.StoredBytes
+	}
+	return 0
+}
+
+func (x *ClusterUsage) GetDedupRatio() float64 {
+	if x != nil {
+		return x.DedupRatio
+	}
+	return 0
+}
+
 type ListNodesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Nodes         []string               `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	Statuses      []*NodeStatus          `protobuf:"bytes,2,rep,name=statuses,proto3" json:"statuses,omitempty"`
+	Usage         *ClusterUsage          `protobuf:"bytes,3,opt,name=usage,proto3" json:"usage,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListNodesResponse) Reset() {
 	*x = ListNodesResponse{}
-	mi := &file_proto_admin_proto_msgTypes[5]
+	mi := &file_proto_admin_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -254,7 +453,7 @@ func (x *ListNodesResponse) String() string {
 func (*ListNodesResponse) ProtoMessage() {}
 
 func (x *ListNodesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_admin_proto_msgTypes[5]
+	mi := &file_proto_admin_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -267,7 +466,7 @@ func (x *ListNodesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListNodesResponse.ProtoReflect.Descriptor instead.
 func (*ListNodesResponse) Descriptor() ([]byte, []int) {
-	return file_proto_admin_proto_rawDescGZIP(), []int{5}
+	return file_proto_admin_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ListNodesResponse) GetNodes() []string {
@@ -277,28 +476,1279 @@ func (x *ListNodesResponse) GetNodes() []string {
 	return nil
 }
 
-var File_proto_admin_proto protoreflect.FileDescriptor
+func (x *ListNodesResponse) GetStatuses() []*NodeStatus {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
 
-const file_proto_admin_proto_rawDesc = "" +
-	"\n" +
-	"\x11proto/admin.proto\x12\n" +
-	"tritontube\"3\n" +
-	"\x0eAddNodeRequest\x12!\n" +
-	"\fnode_address\x18\x01 \x01(\tR\vnodeAddress\"A\n" +
-	"\x0fAddNodeResponse\x12.\n" +
-	"\x13migrated_file_count\x18\x01 \x01(\x05R\x11migratedFileCount\"6\n" +
-	"\x11RemoveNodeRequest\x12!\n" +
-	"\fnode_address\x18\x01 \x01(\tR\vnodeAddress\"D\n" +
-	"\x12RemoveNodeResponse\x12.\n" +
-	"\x13migrated_file_count\x18\x01 \x01(\x05R\x11migratedFileCount\"\x12\n" +
-	"\x10ListNodesRequest\")\n" +
-	"\x11ListNodesResponse\x12\x14\n" +
-	"\x05nodes\x18\x01 \x03(\tR\x05nodes2\xf5\x01\n" +
+func (x *ListNodesResponse) GetUsage() *ClusterUsage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+type RebalanceStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OperationId   string                 `protobuf:"bytes,1,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`
+	Kind          string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	NodeAddress   string                 `protobuf:"bytes,3,opt,name=node_address,json=nodeAddress,proto3" json:"node_address,omitempty"`
+	State         string                 `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`
+	TotalFiles    int64                  `protobuf:"varint,5,opt,name=total_files,json=totalFiles,proto3" json:"total_files,omitempty"`
+	TotalBytes    int64                  `protobuf:"varint,6,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	MovedFiles    int64                  `protobuf:"varint,7,opt,name=moved_files,json=movedFiles,proto3" json:"moved_files,omitempty"`
+	MovedBytes    int64                  `protobuf:"varint,8,opt,name=moved_bytes,json=movedBytes,proto3" json:"moved_bytes,omitempty"`
+	FailedFiles   int64                  `protobuf:"varint,9,opt,name=failed_files,json=failedFiles,proto3" json:"failed_files,omitempty"`
+	Error         string                 `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     int64                  `protobuf:"varint,12,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RebalanceStatus) Reset() {
+	*x = RebalanceStatus{}
+	mi := &file_proto_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RebalanceStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RebalanceStatus) ProtoMessage() {}
+
+func (x *RebalanceStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RebalanceStatus.ProtoReflect.Descriptor instead.
+func (*RebalanceStatus) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RebalanceStatus) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+func (x *RebalanceStatus) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *RebalanceStatus) GetNodeAddress() string {
+	if x != nil {
+		return x.NodeAddress
+	}
+	return ""
+}
+
+func (x *RebalanceStatus) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *RebalanceStatus) GetTotalFiles() int64 {
+	if x != nil {
+		return x.TotalFiles
+	}
+	return 0
+}
+
+func (x *RebalanceStatus) GetTotalBytes() int64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *RebalanceStatus) GetMovedFiles() int64 {
+	if x != nil {
+		return x.MovedFiles
+	}
+	return 0
+}
+
+func (x *RebalanceStatus) GetMovedBytes() int64 {
+	if x != nil {
+		return x.MovedBytes
+	}
+	return 0
+}
+
+func (x *RebalanceStatus) GetFailedFiles() int64 {
+	if x != nil {
+		return x.FailedFiles
+	}
+	return 0
+}
+
+func (x *RebalanceStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *RebalanceStatus) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *RebalanceStatus) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type ListRebalancesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRebalancesRequest) Reset() {
+	*x = ListRebalancesRequest{}
+	mi := &file_proto_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRebalancesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRebalancesRequest) ProtoMessage() {}
+
+func (x *ListRebalancesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRebalancesRequest.ProtoReflect.Descriptor instead.
+func (*ListRebalancesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{9}
+}
+
+type ListRebalancesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Operations    []*RebalanceStatus     `protobuf:"bytes,1,rep,name=operations,proto3" json:"operations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRebalancesResponse) Reset() {
+	*x = ListRebalancesResponse{}
+	mi := &file_proto_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRebalancesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRebalancesResponse) ProtoMessage() {}
+
+func (x *ListRebalancesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRebalancesResponse.ProtoReflect.Descriptor instead.
+func (*ListRebalancesResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListRebalancesResponse) GetOperations() []*RebalanceStatus {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+type GetRebalanceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OperationId   string                 `protobuf:"bytes,1,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRebalanceRequest) Reset() {
+	*x = GetRebalanceRequest{}
+	mi := &file_proto_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRebalanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRebalanceRequest) ProtoMessage() {}
+
+func (x *GetRebalanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRebalanceRequest.ProtoReflect.Descriptor instead.
+func (*GetRebalanceRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetRebalanceRequest) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+type PlanMembershipChangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Action        string                 `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"`
+	NodeAddress   string                 `protobuf:"bytes,2,opt,name=node_address,json=nodeAddress,proto3" json:"node_address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanMembershipChangeRequest) Reset() {
+	*x = PlanMembershipChangeRequest{}
+	mi := &file_proto_admin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanMembershipChangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanMembershipChangeRequest) ProtoMessage() {}
+
+func (x *PlanMembershipChangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanMembershipChangeRequest.ProtoReflect.Descriptor instead.
+func (*PlanMembershipChangeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PlanMembershipChangeRequest) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *PlanMembershipChangeRequest) GetNodeAddress() string {
+	if x != nil {
+		return x.NodeAddress
+	}
+	return ""
+}
+
+type PlannedTransfer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Target        string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	FileCount     int64                  `protobuf:"varint,3,opt,name=file_count,json=fileCount,proto3" json:"file_count,omitempty"`
+	Bytes         int64                  `protobuf:"varint,4,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlannedTransfer) Reset() {
+	*x = PlannedTransfer{}
+	mi := &file_proto_admin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlannedTransfer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlannedTransfer) ProtoMessage() {}
+
+func (x *PlannedTransfer) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlannedTransfer.ProtoReflect.Descriptor instead.
+func (*PlannedTransfer) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *PlannedTransfer) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *PlannedTransfer) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *PlannedTransfer) GetFileCount() int64 {
+	if x != nil {
+		return x.FileCount
+	}
+	return 0
+}
+
+func (x *PlannedTransfer) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+type PlanMembershipChangeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nodes         []string               `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	Transfers     []*PlannedTransfer     `protobuf:"bytes,2,rep,name=transfers,proto3" json:"transfers,omitempty"`
+	TotalFiles    int64                  `protobuf:"varint,3,opt,name=total_files,json=totalFiles,proto3" json:"total_files,omitempty"`
+	TotalBytes    int64                  `protobuf:"varint,4,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanMembershipChangeResponse) Reset() {
+	*x = PlanMembershipChangeResponse{}
+	mi := &file_proto_admin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanMembershipChangeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanMembershipChangeResponse) ProtoMessage() {}
+
+func (x *PlanMembershipChangeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanMembershipChangeResponse.ProtoReflect.Descriptor instead.
+func (*PlanMembershipChangeResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *PlanMembershipChangeResponse) GetNodes() []string {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *PlanMembershipChangeResponse) GetTransfers() []*PlannedTransfer {
+	if x != nil {
+		return x.Transfers
+	}
+	return nil
+}
+
+func (x *PlanMembershipChangeResponse) GetTotalFiles() int64 {
+	if x != nil {
+		return x.TotalFiles
+	}
+	return 0
+}
+
+func (x *PlanMembershipChangeResponse) GetTotalBytes() int64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+type DrainNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeAddress   string                 `protobuf:"bytes,1,opt,name=node_address,json=nodeAddress,proto3" json:"node_address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DrainNodeRequest) Reset() {
+	*x = DrainNodeRequest{}
+	mi := &file_proto_admin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DrainNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrainNodeRequest) ProtoMessage() {}
+
+func (x *DrainNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrainNodeRequest.ProtoReflect.Descriptor instead.
+func (*DrainNodeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DrainNodeRequest) GetNodeAddress() string {
+	if x != nil {
+		return x.NodeAddress
+	}
+	return ""
+}
+
+type DrainNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OperationId   string                 `protobuf:"bytes,1,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DrainNodeResponse) Reset() {
+	*x = DrainNodeResponse{}
+	mi := &file_proto_admin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DrainNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrainNodeResponse) ProtoMessage() {}
+
+func (x *DrainNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrainNodeResponse.ProtoReflect.Descriptor instead.
+func (*DrainNodeResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DrainNodeResponse) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+type UndrainNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeAddress   string                 `protobuf:"bytes,1,opt,name=node_address,json=nodeAddress,proto3" json:"node_address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UndrainNodeRequest) Reset() {
+	*x = UndrainNodeRequest{}
+	mi := &file_proto_admin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UndrainNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UndrainNodeRequest) ProtoMessage() {}
+
+func (x *UndrainNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UndrainNodeRequest.ProtoReflect.Descriptor instead.
+func (*UndrainNodeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *UndrainNodeRequest) GetNodeAddress() string {
+	if x != nil {
+		return x.NodeAddress
+	}
+	return ""
+}
+
+type UndrainNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OperationId   string                 `protobuf:"bytes,1,opt,name=operation_id,json=operationId,proto3" json:"operation_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UndrainNodeResponse) Reset() {
+	*x = UndrainNodeResponse{}
+	mi := &file_proto_admin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UndrainNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UndrainNodeResponse) ProtoMessage() {}
+
+func (x *UndrainNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UndrainNodeResponse.ProtoReflect.Descriptor instead.
+func (*UndrainNodeResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *UndrainNodeResponse) GetOperationId() string {
+	if x != nil {
+		return x.OperationId
+	}
+	return ""
+}
+
+type DecommissionNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeAddress   string                 `protobuf:"bytes,1,opt,name=node_address,json=nodeAddress,proto3" json:"node_address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DecommissionNodeRequest) Reset() {
+	*x = DecommissionNodeRequest{}
+	mi := &file_proto_admin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DecommissionNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecommissionNodeRequest) ProtoMessage() {}
+
+func (x *DecommissionNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecommissionNodeRequest.ProtoReflect.Descriptor instead.
+func (*DecommissionNodeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DecommissionNodeRequest) GetNodeAddress() string {
+	if x != nil {
+		return x.NodeAddress
+	}
+	return ""
+}
+
+type DecommissionNodeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VerifiedFiles int64                  `protobuf:"varint,1,opt,name=verified_files,json=verifiedFiles,proto3" json:"verified_files,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DecommissionNodeResponse) Reset() {
+	*x = DecommissionNodeResponse{}
+	mi := &file_proto_admin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DecommissionNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecommissionNodeResponse) ProtoMessage() {}
+
+func (x *DecommissionNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecommissionNodeResponse.ProtoReflect.Descriptor instead.
+func (*DecommissionNodeResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *DecommissionNodeResponse) GetVerifiedFiles() int64 {
+	if x != nil {
+		return x.VerifiedFiles
+	}
+	return 0
+}
+
+type CollectGarbageRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// dry_run reports orphaned files without deleting them.
+	DryRun bool `protobuf:"varint,1,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	// grace_seconds spares files written more recently than this; 0 uses the
+	// server's default.
+	GraceSeconds  int64 `protobuf:"varint,2,opt,name=grace_seconds,json=graceSeconds,proto3" json:"grace_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectGarbageRequest) Reset() {
+	*x = CollectGarbageRequest{}
+	mi := &file_proto_admin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectGarbageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectGarbageRequest) ProtoMessage() {}
+
+func (x *CollectGarbageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectGarbageRequest.ProtoReflect.Descriptor instead.
+func (*CollectGarbageRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CollectGarbageRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *CollectGarbageRequest) GetGraceSeconds() int64 {
+	if x != nil {
+		return x.GraceSeconds
+	}
+	return 0
+}
+
+type OrphanFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeAddress   string                 `protobuf:"bytes,1,opt,name=node_address,json=nodeAddress,proto3" json:"node_address,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	ModTime       int64                  `protobuf:"varint,4,opt,name=mod_time,json=modTime,proto3" json:"mod_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrphanFile) Reset() {
+	*x = OrphanFile{}
+	mi := &file_proto_admin_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrphanFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrphanFile) ProtoMessage() {}
+
+func (x *OrphanFile) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrphanFile.ProtoReflect.Descriptor instead.
+func (*OrphanFile) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *OrphanFile) GetNodeAddress() string {
+	if x != nil {
+		return x.NodeAddress
+	}
+	return ""
+}
+
+func (x *OrphanFile) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *OrphanFile) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *OrphanFile) GetModTime() int64 {
+	if x != nil {
+		return x.ModTime
+	}
+	return 0
+}
+
+type CollectGarbageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ScannedFiles  int64                  `protobuf:"varint,1,opt,name=scanned_files,json=scannedFiles,proto3" json:"scanned_files,omitempty"`
+	Orphans       []*OrphanFile          `protobuf:"bytes,2,rep,name=orphans,proto3" json:"orphans,omitempty"`
+	DeletedFiles  int64                  `protobuf:"varint,3,opt,name=deleted_files,json=deletedFiles,proto3" json:"deleted_files,omitempty"`
+	DeletedBytes  int64                  `protobuf:"varint,4,opt,name=deleted_bytes,json=deletedBytes,proto3" json:"deleted_bytes,omitempty"`
+	Errors        []string               `protobuf:"bytes,5,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectGarbageResponse) Reset() {
+	*x = CollectGarbageResponse{}
+	mi := &file_proto_admin_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectGarbageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectGarbageResponse) ProtoMessage() {}
+
+func (x *CollectGarbageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectGarbageResponse.ProtoReflect.Descriptor instead.
+func (*CollectGarbageResponse) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CollectGarbageResponse) GetScannedFiles() int64 {
+	if x != nil {
+		return x.ScannedFiles
+	}
+	return 0
+}
+
+func (x *CollectGarbageResponse) GetOrphans() []*OrphanFile {
+	if x != nil {
+		return x.Orphans
+	}
+	return nil
+}
+
+func (x *CollectGarbageResponse) GetDeletedFiles() int64 {
+	if x != nil {
+		return x.DeletedFiles
+	}
+	return 0
+}
+
+func (x *CollectGarbageResponse) GetDeletedBytes() int64 {
+	if x != nil {
+		return x.DeletedBytes
+	}
+	return 0
+}
+
+func (x *CollectGarbageResponse) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+type CheckConsistencyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// video_ids limits the check to these videos; empty checks every video.
+	VideoIds []string `protobuf:"bytes,1,rep,name=video_ids,json=videoIds,proto3" json:"video_ids,omitempty"`
+	// repair copies missing or corrupt files to their owner from an intact
+	// copy on another node.
+	Repair        bool `protobuf:"varint,2,opt,name=repair,proto3" json:"repair,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckConsistencyRequest) Reset() {
+	*x = CheckConsistencyRequest{}
+	mi := &file_proto_admin_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckConsistencyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckConsistencyRequest) ProtoMessage() {}
+
+func (x *CheckConsistencyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckConsistencyRequest.ProtoReflect.Descriptor instead.
+func (*CheckConsistencyRequest) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CheckConsistencyRequest) GetVideoIds() []string {
+	if x != nil {
+		return x.VideoIds
+	}
+	return nil
+}
+
+func (x *CheckConsistencyRequest) GetRepair() bool {
+	if x != nil {
+		return x.Repair
+	}
+	return false
+}
+
+type SegmentProblem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	NodeAddress   string                 `protobuf:"bytes,2,opt,name=node_address,json=nodeAddress,proto3" json:"node_address,omitempty"`
+	Problem       string                 `protobuf:"bytes,3,opt,name=problem,proto3" json:"problem,omitempty"`
+	Repaired      bool                   `protobuf:"varint,4,opt,name=repaired,proto3" json:"repaired,omitempty"`
+	RepairedFrom  string                 `protobuf:"bytes,5,opt,name=repaired_from,json=repairedFrom,proto3" json:"repaired_from,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SegmentProblem) Reset() {
+	*x = SegmentProblem{}
+	mi := &file_proto_admin_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentProblem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentProblem) ProtoMessage() {}
+
+func (x *SegmentProblem) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentProblem.ProtoReflect.Descriptor instead.
+func (*SegmentProblem) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SegmentProblem) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *SegmentProblem) GetNodeAddress() string {
+	if x != nil {
+		return x.NodeAddress
+	}
+	return ""
+}
+
+func (x *SegmentProblem) GetProblem() string {
+	if x != nil {
+		return x.Problem
+	}
+	return ""
+}
+
+func (x *SegmentProblem) GetRepaired() bool {
+	if x != nil {
+		return x.Repaired
+	}
+	return false
+}
+
+func (x *SegmentProblem) GetRepairedFrom() string {
+	if x != nil {
+		return x.RepairedFrom
+	}
+	return ""
+}
+
+type VideoHealth struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	VideoId string                 `protobuf:"bytes,1,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	Healthy bool                   `protobuf:"varint,2,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	// files counts the manifest and every segment it references.
+	Files         int32             `protobuf:"varint,3,opt,name=files,proto3" json:"files,omitempty"`
+	Problems      []*SegmentProblem `protobuf:"bytes,4,rep,name=problems,proto3" json:"problems,omitempty"`
+	Error         string            `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VideoHealth) Reset() {
+	*x = VideoHealth{}
+	mi := &file_proto_admin_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VideoHealth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VideoHealth) ProtoMessage() {}
+
+func (x *VideoHealth) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_admin_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VideoHealth.ProtoReflect.Descriptor instead.
+func (*VideoHealth) Descriptor() ([]byte, []int) {
+	return file_proto_admin_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *VideoHealth) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *VideoHealth) GetHealthy() bool {
+	if x != nil {
+		return x.Healthy
+	}
+	return false
+}
+
+func (x *VideoHealth) GetFiles() int32 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+func (x *VideoHealth) GetProblems() []*SegmentProblem {
+	if x != nil {
+		return x.Problems
+	}
+	return nil
+}
+
+func (x *VideoHealth) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_proto_admin_proto protoreflect.FileDescriptor
+
+const file_proto_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x11proto/admin.proto\x12\n" +
+	"tritontube\x1a\x13proto/storage.proto\"3\n" +
+	"\x0eAddNodeRequest\x12!\n" +
+	"\fnode_address\x18\x01 \x01(\tR\vnodeAddress\"h\n" +
+	"\x0fAddNodeResponse\x122\n" +
+	"\x13migrated_file_count\x18\x01 \x01(\x05B\x02\x18\x01R\x11migratedFileCount\x12!\n" +
+	"\foperation_id\x18\x02 \x01(\tR\voperationId\"6\n" +
+	"\x11RemoveNodeRequest\x12!\n" +
+	"\fnode_address\x18\x01 \x01(\tR\vnodeAddress\"k\n" +
+	"\x12RemoveNodeResponse\x122\n" +
+	"\x13migrated_file_count\x18\x01 \x01(\x05B\x02\x18\x01R\x11migratedFileCount\x12!\n" +
+	"\foperation_id\x18\x02 \x01(\tR\voperationId\"7\n" +
+	"\x10ListNodesRequest\x12#\n" +
+	"\rinclude_stats\x18\x01 \x01(\bR\fincludeStats\"\x91\x01\n" +
+	"\n" +
+	"NodeStatus\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x122\n" +
+	"\x05stats\x18\x03 \x01(\v2\x1c.tritontube.GetStatsResponseR\x05stats\x12\x1f\n" +
+	"\vstats_error\x18\x04 \x01(\tR\n" +
+	"statsError\"\x87\x02\n" +
+	"\fClusterUsage\x12\x1d\n" +
+	"\n" +
+	"file_count\x18\x01 \x01(\x03R\tfileCount\x12\x1d\n" +
+	"\n" +
+	"bytes_used\x18\x02 \x01(\x03R\tbytesUsed\x12\x1d\n" +
+	"\n" +
+	"free_bytes\x18\x03 \x01(\x03R\tfreeBytes\x12\x1f\n" +
+	"\vtotal_bytes\x18\x04 \x01(\x03R\n" +
+	"totalBytes\x125\n" +
+	"\n" +
+	"top_videos\x18\x05 \x03(\v2\x16.tritontube.VideoUsageR\ttopVideos\x12!\n" +
+	"\fstored_bytes\x18\x06 \x01(\x03R\vstoredBytes\x12\x1f\n" +
+	"\vdedup_ratio\x18\a \x01(\x01R\n" +
+	"dedupRatio\"\x8d\x01\n" +
+	"\x11ListNodesResponse\x12\x14\n" +
+	"\x05nodes\x18\x01 \x03(\tR\x05nodes\x122\n" +
+	"\bstatuses\x18\x02 \x03(\v2\x16.tritontube.NodeStatusR\bstatuses\x12.\n" +
+	"\x05usage\x18\x03 \x01(\v2\x18.tritontube.ClusterUsageR\x05usage\"\xfc\x02\n" +
+	"\x0fRebalanceStatus\x12!\n" +
+	"\foperation_id\x18\x01 \x01(\tR\voperationId\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12!\n" +
+	"\fnode_address\x18\x03 \x01(\tR\vnodeAddress\x12\x14\n" +
+	"\x05state\x18\x04 \x01(\tR\x05state\x12\x1f\n" +
+	"\vtotal_files\x18\x05 \x01(\x03R\n" +
+	"totalFiles\x12\x1f\n" +
+	"\vtotal_bytes\x18\x06 \x01(\x03R\n" +
+	"totalBytes\x12\x1f\n" +
+	"\vmoved_files\x18\a \x01(\x03R\n" +
+	"movedFiles\x12\x1f\n" +
+	"\vmoved_bytes\x18\b \x01(\x03R\n" +
+	"movedBytes\x12!\n" +
+	"\ffailed_files\x18\t \x01(\x03R\vfailedFiles\x12\x14\n" +
+	"\x05error\x18\n" +
+	" \x01(\tR\x05error\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\v \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\f \x01(\x03R\tupdatedAt\"\x17\n" +
+	"\x15ListRebalancesRequest\"U\n" +
+	"\x16ListRebalancesResponse\x12;\n" +
+	"\n" +
+	"operations\x18\x01 \x03(\v2\x1b.tritontube.RebalanceStatusR\n" +
+	"operations\"8\n" +
+	"\x13GetRebalanceRequest\x12!\n" +
+	"\foperation_id\x18\x01 \x01(\tR\voperationId\"X\n" +
+	"\x1bPlanMembershipChangeRequest\x12\x16\n" +
+	"\x06action\x18\x01 \x01(\tR\x06action\x12!\n" +
+	"\fnode_address\x18\x02 \x01(\tR\vnodeAddress\"v\n" +
+	"\x0fPlannedTransfer\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\x12\x1d\n" +
+	"\n" +
+	"file_count\x18\x03 \x01(\x03R\tfileCount\x12\x14\n" +
+	"\x05bytes\x18\x04 \x01(\x03R\x05bytes\"\xb1\x01\n" +
+	"\x1cPlanMembershipChangeResponse\x12\x14\n" +
+	"\x05nodes\x18\x01 \x03(\tR\x05nodes\x129\n" +
+	"\ttransfers\x18\x02 \x03(\v2\x1b.tritontube.PlannedTransferR\ttransfers\x12\x1f\n" +
+	"\vtotal_files\x18\x03 \x01(\x03R\n" +
+	"totalFiles\x12\x1f\n" +
+	"\vtotal_bytes\x18\x04 \x01(\x03R\n" +
+	"totalBytes\"5\n" +
+	"\x10DrainNodeRequest\x12!\n" +
+	"\fnode_address\x18\x01 \x01(\tR\vnodeAddress\"6\n" +
+	"\x11DrainNodeResponse\x12!\n" +
+	"\foperation_id\x18\x01 \x01(\tR\voperationId\"7\n" +
+	"\x12UndrainNodeRequest\x12!\n" +
+	"\fnode_address\x18\x01 \x01(\tR\vnodeAddress\"8\n" +
+	"\x13UndrainNodeResponse\x12!\n" +
+	"\foperation_id\x18\x01 \x01(\tR\voperationId\"<\n" +
+	"\x17DecommissionNodeRequest\x12!\n" +
+	"\fnode_address\x18\x01 \x01(\tR\vnodeAddress\"A\n" +
+	"\x18DecommissionNodeResponse\x12%\n" +
+	"\x0everified_files\x18\x01 \x01(\x03R\rverifiedFiles\"U\n" +
+	"\x15CollectGarbageRequest\x12\x17\n" +
+	"\adry_run\x18\x01 \x01(\bR\x06dryRun\x12#\n" +
+	"\rgrace_seconds\x18\x02 \x01(\x03R\fgraceSeconds\"r\n" +
+	"\n" +
+	"OrphanFile\x12!\n" +
+	"\fnode_address\x18\x01 \x01(\tR\vnodeAddress\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x19\n" +
+	"\bmod_time\x18\x04 \x01(\x03R\amodTime\"\xd1\x01\n" +
+	"\x16CollectGarbageResponse\x12#\n" +
+	"\rscanned_files\x18\x01 \x01(\x03R\fscannedFiles\x120\n" +
+	"\aorphans\x18\x02 \x03(\v2\x16.tritontube.OrphanFileR\aorphans\x12#\n" +
+	"\rdeleted_files\x18\x03 \x01(\x03R\fdeletedFiles\x12#\n" +
+	"\rdeleted_bytes\x18\x04 \x01(\x03R\fdeletedBytes\x12\x16\n" +
+	"\x06errors\x18\x05 \x03(\tR\x06errors\"N\n" +
+	"\x17CheckConsistencyRequest\x12\x1b\n" +
+	"\tvideo_ids\x18\x01 \x03(\tR\bvideoIds\x12\x16\n" +
+	"\x06repair\x18\x02 \x01(\bR\x06repair\"\xaa\x01\n" +
+	"\x0eSegmentProblem\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12!\n" +
+	"\fnode_address\x18\x02 \x01(\tR\vnodeAddress\x12\x18\n" +
+	"\aproblem\x18\x03 \x01(\tR\aproblem\x12\x1a\n" +
+	"\brepaired\x18\x04 \x01(\bR\brepaired\x12#\n" +
+	"\rrepaired_from\x18\x05 \x01(\tR\frepairedFrom\"\xa6\x01\n" +
+	"\vVideoHealth\x12\x19\n" +
+	"\bvideo_id\x18\x01 \x01(\tR\avideoId\x12\x18\n" +
+	"\ahealthy\x18\x02 \x01(\bR\ahealthy\x12\x14\n" +
+	"\x05files\x18\x03 \x01(\x05R\x05files\x126\n" +
+	"\bproblems\x18\x04 \x03(\v2\x1a.tritontube.SegmentProblemR\bproblems\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error2\xa1\t\n" +
 	"\x18VideoContentAdminService\x12B\n" +
 	"\aAddNode\x12\x1a.tritontube.AddNodeRequest\x1a\x1b.tritontube.AddNodeResponse\x12K\n" +
 	"\n" +
 	"RemoveNode\x12\x1d.tritontube.RemoveNodeRequest\x1a\x1e.tritontube.RemoveNodeResponse\x12H\n" +
-	"\tListNodes\x12\x1c.tritontube.ListNodesRequest\x1a\x1d.tritontube.ListNodesResponseB\x16Z\x14internal/proto;protob\x06proto3"
+	"\tListNodes\x12\x1c.tritontube.ListNodesRequest\x1a\x1d.tritontube.ListNodesResponse\x12W\n" +
+	"\x0eListRebalances\x12!.tritontube.ListRebalancesRequest\x1a\".tritontube.ListRebalancesResponse\x12L\n" +
+	"\fGetRebalance\x12\x1f.tritontube.GetRebalanceRequest\x1a\x1b.tritontube.RebalanceStatus\x12P\n" +
+	"\x0eWatchRebalance\x12\x1f.tritontube.GetRebalanceRequest\x1a\x1b.tritontube.RebalanceStatus0\x01\x12O\n" +
+	"\x0fCancelRebalance\x12\x1f.tritontube.GetRebalanceRequest\x1a\x1b.tritontube.RebalanceStatus\x12O\n" +
+	"\x0fResumeRebalance\x12\x1f.tritontube.GetRebalanceRequest\x1a\x1b.tritontube.RebalanceStatus\x12i\n" +
+	"\x14PlanMembershipChange\x12'.tritontube.PlanMembershipChangeRequest\x1a(.tritontube.PlanMembershipChangeResponse\x12H\n" +
+	"\tDrainNode\x12\x1c.tritontube.DrainNodeRequest\x1a\x1d.tritontube.DrainNodeResponse\x12]\n" +
+	"\x10DecommissionNode\x12#.tritontube.DecommissionNodeRequest\x1a$.tritontube.DecommissionNodeResponse\x12N\n" +
+	"\vUndrainNode\x12\x1e.tritontube.UndrainNodeRequest\x1a\x1f.tritontube.UndrainNodeResponse\x12W\n" +
+	"\x0eCollectGarbage\x12!.tritontube.CollectGarbageRequest\x1a\".tritontube.CollectGarbageResponse\x12R\n" +
+	"\x10CheckConsistency\x12#.tritontube.CheckConsistencyRequest\x1a\x17.tritontube.VideoHealth0\x01B\x16Z\x14internal/proto;protob\x06proto3"
 
 var (
 	file_proto_admin_proto_rawDescOnce sync.Once
@@ -312,27 +1762,80 @@ func file_proto_admin_proto_rawDescGZIP() []byte {
 	return file_proto_admin_proto_rawDescData
 }
 
-var file_proto_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
 var file_proto_admin_proto_goTypes = []any{
-	(*AddNodeRequest)(nil),     // 0: tritontube.AddNodeRequest
-	(*AddNodeResponse)(nil),    // 1: tritontube.AddNodeResponse
-	(*RemoveNodeRequest)(nil),  // 2: tritontube.RemoveNodeRequest
-	(*RemoveNodeResponse)(nil), // 3: tritontube.RemoveNodeResponse
-	(*ListNodesRequest)(nil),   // 4: tritontube.ListNodesRequest
-	(*ListNodesResponse)(nil),  // 5: tritontube.ListNodesResponse
+	(*AddNodeRequest)(nil),               // 0: tritontube.AddNodeRequest
+	(*AddNodeResponse)(nil),              // 1: tritontube.AddNodeResponse
+	(*RemoveNodeRequest)(nil),            // 2: tritontube.RemoveNodeRequest
+	(*RemoveNodeResponse)(nil),           // 3: tritontube.RemoveNodeResponse
+	(*ListNodesRequest)(nil),             // 4: tritontube.ListNodesRequest
+	(*NodeStatus)(nil),                   // 5: tritontube.NodeStatus
+	(*ClusterUsage)(nil),                 // 6: tritontube.ClusterUsage
+	(*ListNodesResponse)(nil),            // 7: tritontube.ListNodesResponse
+	(*RebalanceStatus)(nil),              // 8: tritontube.RebalanceStatus
+	(*ListRebalancesRequest)(nil),        // 9: tritontube.ListRebalancesRequest
+	(*ListRebalancesResponse)(nil),       // 10: tritontube.ListRebalancesResponse
+	(*GetRebalanceRequest)(nil),          // 11: tritontube.GetRebalanceRequest
+	(*PlanMembershipChangeRequest)(nil),  // 12: tritontube.PlanMembershipChangeRequest
+	(*PlannedTransfer)(nil),              // 13: tritontube.PlannedTransfer
+	(*PlanMembershipChangeResponse)(nil), // 14: tritontube.PlanMembershipChangeResponse
+	(*DrainNodeRequest)(nil),             // 15: tritontube.DrainNodeRequest
+	(*DrainNodeResponse)(nil),            // 16: tritontube.DrainNodeResponse
+	(*UndrainNodeRequest)(nil),           // 17: tritontube.UndrainNodeRequest
+	(*UndrainNodeResponse)(nil),          // 18: tritontube.UndrainNodeResponse
+	(*DecommissionNodeRequest)(nil),      // 19: tritontube.DecommissionNodeRequest
+	(*DecommissionNodeResponse)(nil),     // 20: tritontube.DecommissionNodeResponse
+	(*CollectGarbageRequest)(nil),        // 21: tritontube.CollectGarbageRequest
+	(*OrphanFile)(nil),                   // 22: tritontube.OrphanFile
+	(*CollectGarbageResponse)(nil),       // 23: tritontube.CollectGarbageResponse
+	(*CheckConsistencyRequest)(nil),      // 24: tritontube.CheckConsistencyRequest
+	(*SegmentProblem)(nil),               // 25: tritontube.SegmentProblem
+	(*VideoHealth)(nil),                  // 26: tritontube.VideoHealth
+	(*GetStatsResponse)(nil),             // 27: tritontube.GetStatsResponse
+	(*VideoUsage)(nil),                   // 28: tritontube.VideoUsage
 }
 var file_proto_admin_proto_depIdxs = []int32{
-	0, // 0: tritontube.VideoContentAdminService.AddNode:input_type -> tritontube.AddNodeRequest
-	2, // 1: tritontube.VideoContentAdminService.RemoveNode:input_type -> tritontube.RemoveNodeRequest
-	4, // 2: tritontube.VideoContentAdminService.ListNodes:input_type -> tritontube.ListNodesRequest
-	1, // 3: tritontube.VideoContentAdminService.AddNode:output_type -> tritontube.AddNodeResponse
-	3, // 4: tritontube.VideoContentAdminService.RemoveNode:output_type -> tritontube.RemoveNodeResponse
-	5, // 5: tritontube.VideoContentAdminService.ListNodes:output_type -> tritontube.ListNodesResponse
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	27, // 0: tritontube.NodeStatus.stats:type_name -> tritontube.GetStatsResponse
+	28, // 1: tritontube.ClusterUsage.top_videos:type_name -> tritontube.VideoUsage
+	5,  // 2: tritontube.ListNodesResponse.statuses:type_name -> tritontube.NodeStatus
+	6,  // 3: tritontube.ListNodesResponse.usage:type_name -> tritontube.ClusterUsage
+	8,  // 4: tritontube.ListRebalancesResponse.operations:type_name -> tritontube.RebalanceStatus
+	13, // 5: tritontube.PlanMembershipChangeResponse.transfers:type_name -> tritontube.PlannedTransfer
+	22, // 6: tritontube.CollectGarbageResponse.orphans:type_name -> tritontube.OrphanFile
+	25, // 7: tritontube.VideoHealth.problems:type_name -> tritontube.SegmentProblem
+	0,  // 8: tritontube.VideoContentAdminService.AddNode:input_type -> tritontube.AddNodeRequest
+	2,  // 9: tritontube.VideoContentAdminService.RemoveNode:input_type -> tritontube.RemoveNodeRequest
+	4,  // 10: tritontube.VideoContentAdminService.ListNodes:input_type -> tritontube.ListNodesRequest
+	9,  // 11: tritontube.VideoContentAdminService.ListRebalances:input_type -> tritontube.ListRebalancesRequest
+	11, // 12: tritontube.VideoContentAdminService.GetRebalance:input_type -> tritontube.GetRebalanceRequest
+	11, // 13: tritontube.VideoContentAdminService.WatchRebalance:input_type -> tritontube.GetRebalanceRequest
+	11, // 14: tritontube.VideoContentAdminService.CancelRebalance:input_type -> tritontube.GetRebalanceRequest
+	11, // 15: tritontube.VideoContentAdminService.ResumeRebalance:input_type -> tritontube.GetRebalanceRequest
+	12, // 16: tritontube.VideoContentAdminService.PlanMembershipChange:input_type -> tritontube.PlanMembershipChangeRequest
+	15, // 17: tritontube.VideoContentAdminService.DrainNode:input_type -> tritontube.DrainNodeRequest
+	19, // 18: tritontube.VideoContentAdminService.DecommissionNode:input_type -> tritontube.DecommissionNodeRequest
+	17, // 19: tritontube.VideoContentAdminService.UndrainNode:input_type -> tritontube.UndrainNodeRequest
+	21, // 20: tritontube.VideoContentAdminService.CollectGarbage:input_type -> tritontube.CollectGarbageRequest
+	24, // 21: tritontube.VideoContentAdminService.CheckConsistency:input_type -> tritontube.CheckConsistencyRequest
+	1,  // 22: tritontube.VideoContentAdminService.AddNode:output_type -> tritontube.AddNodeResponse
+	3,  // 23: tritontube.VideoContentAdminService.RemoveNode:output_type -> tritontube.RemoveNodeResponse
+	7,  // 24: tritontube.VideoContentAdminService.ListNodes:output_type -> tritontube.ListNodesResponse
+	10, // 25: tritontube.VideoContentAdminService.ListRebalances:output_type -> tritontube.ListRebalancesResponse
+	8,  // 26: tritontube.VideoContentAdminService.GetRebalance:output_type -> tritontube.RebalanceStatus
+	8,  // 27: tritontube.VideoContentAdminService.WatchRebalance:output_type -> tritontube.RebalanceStatus
+	8,  // 28: tritontube.VideoContentAdminService.CancelRebalance:output_type -> tritontube.RebalanceStatus
+	8,  // 29: tritontube.VideoContentAdminService.ResumeRebalance:output_type -> tritontube.RebalanceStatus
+	14, // 30: tritontube.VideoContentAdminService.PlanMembershipChange:output_type -> tritontube.PlanMembershipChangeResponse
+	16, // 31: tritontube.VideoContentAdminService.DrainNode:output_type -> tritontube.DrainNodeResponse
+	20, // 32: tritontube.VideoContentAdminService.DecommissionNode:output_type -> tritontube.DecommissionNodeResponse
+	18, // 33: tritontube.VideoContentAdminService.UndrainNode:output_type -> tritontube.UndrainNodeResponse
+	23, // 34: tritontube.VideoContentAdminService.CollectGarbage:output_type -> tritontube.CollectGarbageResponse
+	26, // 35: tritontube.VideoContentAdminService.CheckConsistency:output_type -> tritontube.VideoHealth
+	22, // [22:36] is the sub-list for method output_type
+	8,  // [8:22] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_proto_admin_proto_init() }
@@ -340,13 +1843,14 @@ func file_proto_admin_proto_init() {
 	if File_proto_admin_proto != nil {
 		return
 	}
+	file_proto_storage_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_admin_proto_rawDesc), len(file_proto_admin_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   27,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,9 +19,20 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	VideoContentAdminService_AddNode_FullMethodName    = "/tritontube.VideoContentAdminService/AddNode"
-	VideoContentAdminService_RemoveNode_FullMethodName = "/tritontube.VideoContentAdminService/RemoveNode"
-	VideoContentAdminService_ListNodes_FullMethodName  = "/tritontube.VideoContentAdminService/ListNodes"
+	VideoContentAdminService_AddNode_FullMethodName              = "/tritontube.VideoContentAdminService/AddNode"
+	VideoContentAdminService_RemoveNode_FullMethodName           = "/tritontube.VideoContentAdminService/RemoveNode"
+	VideoContentAdminService_ListNodes_FullMethodName            = "/tritontube.VideoContentAdminService/ListNodes"
+	VideoContentAdminService_ListRebalances_FullMethodName       = "/tritontube.VideoContentAdminService/ListRebalances"
+	VideoContentAdminService_GetRebalance_FullMethodName         = "/tritontube.VideoContentAdminService/GetRebalance"
+	VideoContentAdminService_WatchRebalance_FullMethodName       = "/tritontube.VideoContentAdminService/WatchRebalance"
+	VideoContentAdminService_CancelRebalance_FullMethodName      = "/tritontube.VideoContentAdminService/CancelRebalance"
+	VideoContentAdminService_ResumeRebalance_FullMethodName      = "/tritontube.VideoContentAdminService/ResumeRebalance"
+	VideoContentAdminService_PlanMembershipChange_FullMethodName = "/tritontube.VideoContentAdminService/PlanMembershipChange"
+	VideoContentAdminService_DrainNode_FullMethodName            = "/tritontube.VideoContentAdminService/DrainNode"
+	VideoContentAdminService_DecommissionNode_FullMethodName     = "/tritontube.VideoContentAdminService/DecommissionNode"
+	VideoContentAdminService_UndrainNode_FullMethodName          = "/tritontube.VideoContentAdminService/UndrainNode"
+	VideoContentAdminService_CollectGarbage_FullMethodName       = "/tritontube.VideoContentAdminService/CollectGarbage"
+	VideoContentAdminService_CheckConsistency_FullMethodName     = "/tritontube.VideoContentAdminService/CheckConsistency"
 )
 
 // VideoContentAdminServiceClient is the client API for VideoContentAdminService service.
@@ -31,6 +42,19 @@ type VideoContentAdminServiceClient interface {
 	AddNode(ctx context.Context, in *AddNodeRequest, opts ...grpc.CallOption) (*AddNodeResponse, error)
 	RemoveNode(ctx context.Context, in *RemoveNodeRequest, opts ...grpc.CallOption) (*RemoveNodeResponse, error)
 	ListNodes(ctx context.Context, in *ListNodesRequest, opts ...grpc.CallOption) (*ListNodesResponse, error)
+	ListRebalances(ctx context.Context, in *ListRebalancesRequest, opts ...grpc.CallOption) (*ListRebalancesResponse, error)
+	GetRebalance(ctx context.Context, in *GetRebalanceRequest, opts ...grpc.CallOption) (*RebalanceStatus, error)
+	WatchRebalance(ctx context.Context, in *GetRebalanceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RebalanceStatus], error)
+	CancelRebalance(ctx context.Context, in *GetRebalanceRequest, opts ...grpc.CallOption) (*RebalanceStatus, error)
+	ResumeRebalance(ctx context.Context, in *GetRebalanceRequest, opts ...grpc.CallOption) (*RebalanceStatus, error)
+	PlanMembershipChange(ctx context.Context, in *PlanMembershipChangeRequest, opts ...grpc.CallOption) (*PlanMembershipChangeResponse, error)
+	DrainNode(ctx context.Context, in *DrainNodeRequest, opts ...grpc.CallOption) (*DrainNodeResponse, error)
+	DecommissionNode(ctx context.Context, in *DecommissionNodeRequest, opts ...grpc.CallOption) (*DecommissionNodeResponse, error)
+	// UndrainNode returns a draining or drained node to the ring, moving back
+	// the files it owns again.
+	UndrainNode(ctx context.Context, in *UndrainNodeRequest, opts ...grpc.CallOption) (*UndrainNodeResponse, error)
+	CollectGarbage(ctx context.Context, in *CollectGarbageRequest, opts ...grpc.CallOption) (*CollectGarbageResponse, error)
+	CheckConsistency(ctx context.Context, in *CheckConsistencyRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[VideoHealth], error)
 }
 
 type videoContentAdminServiceClient struct {
@@ -71,6 +95,134 @@ func (c *videoContentAdminServiceClient) ListNodes(ctx context.Context, in *List
 	return out, nil
 }
 
+func (c *videoContentAdminServiceClient) ListRebalances(ctx context.Context, in *ListRebalancesRequest, opts ...grpc.CallOption) (*ListRebalancesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRebalancesResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_ListRebalances_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) GetRebalance(ctx context.Context, in *GetRebalanceRequest, opts ...grpc.CallOption) (*RebalanceStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RebalanceStatus)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_GetRebalance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) WatchRebalance(ctx context.Context, in *GetRebalanceRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RebalanceStatus], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VideoContentAdminService_ServiceDesc.Streams[0], VideoContentAdminService_WatchRebalance_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetRebalanceRequest, RebalanceStatus]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoContentAdminService_WatchRebalanceClient = grpc.ServerStreamingClient[RebalanceStatus]
+
+func (c *videoContentAdminServiceClient) CancelRebalance(ctx context.Context, in *GetRebalanceRequest, opts ...grpc.CallOption) (*RebalanceStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RebalanceStatus)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_CancelRebalance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) ResumeRebalance(ctx context.Context, in *GetRebalanceRequest, opts ...grpc.CallOption) (*RebalanceStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RebalanceStatus)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_ResumeRebalance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) PlanMembershipChange(ctx context.Context, in *PlanMembershipChangeRequest, opts ...grpc.CallOption) (*PlanMembershipChangeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PlanMembershipChangeResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_PlanMembershipChange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) DrainNode(ctx context.Context, in *DrainNodeRequest, opts ...grpc.CallOption) (*DrainNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DrainNodeResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_DrainNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) DecommissionNode(ctx context.Context, in *DecommissionNodeRequest, opts ...grpc.CallOption) (*DecommissionNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DecommissionNodeResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_DecommissionNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) UndrainNode(ctx context.Context, in *UndrainNodeRequest, opts ...grpc.CallOption) (*UndrainNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UndrainNodeResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_UndrainNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) CollectGarbage(ctx context.Context, in *CollectGarbageRequest, opts ...grpc.CallOption) (*CollectGarbageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectGarbageResponse)
+	err := c.cc.Invoke(ctx, VideoContentAdminService_CollectGarbage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoContentAdminServiceClient) CheckConsistency(ctx context.Context, in *CheckConsistencyRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[VideoHealth], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VideoContentAdminService_ServiceDesc.Streams[1], VideoContentAdminService_CheckConsistency_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CheckConsistencyRequest, VideoHealth]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoContentAdminService_CheckConsistencyClient = grpc.ServerStreamingClient[VideoHealth]
+
 // VideoContentAdminServiceServer is the server API for VideoContentAdminService service.
 // All implementations must embed UnimplementedVideoContentAdminServiceServer
 // for forward compatibility.
@@ -78,6 +230,19 @@ type VideoContentAdminServiceServer interface {
 	AddNode(context.Context, *AddNodeRequest) (*AddNodeResponse, error)
 	RemoveNode(context.Context, *RemoveNodeRequest) (*RemoveNodeResponse, error)
 	ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error)
+	ListRebalances(context.Context, *ListRebalancesRequest) (*ListRebalancesResponse, error)
+	GetRebalance(context.Context, *GetRebalanceRequest) (*RebalanceStatus, error)
+	WatchRebalance(*GetRebalanceRequest, grpc.ServerStreamingServer[RebalanceStatus]) error
+	CancelRebalance(context.Context, *GetRebalanceRequest) (*RebalanceStatus, error)
+	ResumeRebalance(context.Context, *GetRebalanceRequest) (*RebalanceStatus, error)
+	PlanMembershipChange(context.Context, *PlanMembershipChangeRequest) (*PlanMembershipChangeResponse, error)
+	DrainNode(context.Context, *DrainNodeRequest) (*DrainNodeResponse, error)
+	DecommissionNode(context.Context, *DecommissionNodeRequest) (*DecommissionNodeResponse, error)
+	// UndrainNode returns a draining or drained node to the ring, moving back
+	// the files it owns again.
+	UndrainNode(context.Context, *UndrainNodeRequest) (*UndrainNodeResponse, error)
+	CollectGarbage(context.Context, *CollectGarbageRequest) (*CollectGarbageResponse, error)
+	CheckConsistency(*CheckConsistencyRequest, grpc.ServerStreamingServer[VideoHealth]) error
 	mustEmbedUnimplementedVideoContentAdminServiceServer()
 }
 
@@ -97,6 +262,39 @@ func (UnimplementedVideoContentAdminServiceServer) RemoveNode(context.Context, *
 func (UnimplementedVideoContentAdminServiceServer) ListNodes(context.Context, *ListNodesRequest) (*ListNodesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListNodes not implemented")
 }
+func (UnimplementedVideoContentAdminServiceServer) ListRebalances(context.Context, *ListRebalancesRequest) (*ListRebalancesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRebalances not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) GetRebalance(context.Context, *GetRebalanceRequest) (*RebalanceStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRebalance not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) WatchRebalance(*GetRebalanceRequest, grpc.ServerStreamingServer[RebalanceStatus]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchRebalance not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) CancelRebalance(context.Context, *GetRebalanceRequest) (*RebalanceStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelRebalance not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) ResumeRebalance(context.Context, *GetRebalanceRequest) (*RebalanceStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeRebalance not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) PlanMembershipChange(context.Context, *PlanMembershipChangeRequest) (*PlanMembershipChangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlanMembershipChange not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) DrainNode(context.Context, *DrainNodeRequest) (*DrainNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DrainNode not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) DecommissionNode(context.Context, *DecommissionNodeRequest) (*DecommissionNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DecommissionNode not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) UndrainNode(context.Context, *UndrainNodeRequest) (*UndrainNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UndrainNode not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) CollectGarbage(context.Context, *CollectGarbageRequest) (*CollectGarbageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CollectGarbage not implemented")
+}
+func (UnimplementedVideoContentAdminServiceServer) CheckConsistency(*CheckConsistencyRequest, grpc.ServerStreamingServer[VideoHealth]) error {
+	return status.Errorf(codes.Unimplemented, "method CheckConsistency not implemented")
+}
 func (UnimplementedVideoContentAdminServiceServer) mustEmbedUnimplementedVideoContentAdminServiceServer() {
 }
 func (UnimplementedVideoContentAdminServiceServer) testEmbeddedByValue() {}
@@ -173,6 +371,190 @@ func _VideoContentAdminService_ListNodes_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VideoContentAdminService_ListRebalances_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRebalancesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).ListRebalances(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_ListRebalances_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).ListRebalances(ctx, req.(*ListRebalancesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_GetRebalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRebalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).GetRebalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_GetRebalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).GetRebalance(ctx, req.(*GetRebalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_WatchRebalance_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRebalanceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VideoContentAdminServiceServer).WatchRebalance(m, &grpc.GenericServerStream[GetRebalanceRequest, RebalanceStatus]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoContentAdminService_WatchRebalanceServer = grpc.ServerStreamingServer[RebalanceStatus]
+
+func _VideoContentAdminService_CancelRebalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRebalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).CancelRebalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_CancelRebalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).CancelRebalance(ctx, req.(*GetRebalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_ResumeRebalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRebalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).ResumeRebalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_ResumeRebalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).ResumeRebalance(ctx, req.(*GetRebalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_PlanMembershipChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlanMembershipChangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).PlanMembershipChange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_PlanMembershipChange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).PlanMembershipChange(ctx, req.(*PlanMembershipChangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_DrainNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).DrainNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_DrainNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).DrainNode(ctx, req.(*DrainNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_DecommissionNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecommissionNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).DecommissionNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_DecommissionNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).DecommissionNode(ctx, req.(*DecommissionNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_UndrainNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndrainNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).UndrainNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_UndrainNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).UndrainNode(ctx, req.(*UndrainNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_CollectGarbage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CollectGarbageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoContentAdminServiceServer).CollectGarbage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoContentAdminService_CollectGarbage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoContentAdminServiceServer).CollectGarbage(ctx, req.(*CollectGarbageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoContentAdminService_CheckConsistency_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CheckConsistencyRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VideoContentAdminServiceServer).CheckConsistency(m, &grpc.GenericServerStream[CheckConsistencyRequest, VideoHealth]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoContentAdminService_CheckConsistencyServer = grpc.ServerStreamingServer[VideoHealth]
+
 // VideoContentAdminService_ServiceDesc is the grpc.ServiceDesc for VideoContentAdminService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -192,7 +574,54 @@ var VideoContentAdminService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListNodes",
 			Handler:    _VideoContentAdminService_ListNodes_Handler,
 		},
+		{
+			MethodName: "ListRebalances",
+			Handler:    _VideoContentAdminService_ListRebalances_Handler,
+		},
+		{
+			MethodName: "GetRebalance",
+			Handler:    _VideoContentAdminService_GetRebalance_Handler,
+		},
+		{
+			MethodName: "CancelRebalance",
+			Handler:    _VideoContentAdminService_CancelRebalance_Handler,
+		},
+		{
+			MethodName: "ResumeRebalance",
+			Handler:    _VideoContentAdminService_ResumeRebalance_Handler,
+		},
+		{
+			MethodName: "PlanMembershipChange",
+			Handler:    _VideoContentAdminService_PlanMembershipChange_Handler,
+		},
+		{
+			MethodName: "DrainNode",
+			Handler:    _VideoContentAdminService_DrainNode_Handler,
+		},
+		{
+			MethodName: "DecommissionNode",
+			Handler:    _VideoContentAdminService_DecommissionNode_Handler,
+		},
+		{
+			MethodName: "UndrainNode",
+			Handler:    _VideoContentAdminService_UndrainNode_Handler,
+		},
+		{
+			MethodName: "CollectGarbage",
+			Handler:    _VideoContentAdminService_CollectGarbage_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchRebalance",
+			Handler:       _VideoContentAdminService_WatchRebalance_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "CheckConsistency",
+			Handler:       _VideoContentAdminService_CheckConsistency_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/admin.proto",
 }
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tritontube/internal/atomicfile"
+)
+
+// blobDir holds the contents of every stored file once, named by SHA-256.
+// Each videoId/filename is a hard link to its blob, so files with the same
+// contents share their disk space while reads still open them by path.
+const blobDir = ".blobs"
+
+func (s *StorageServer) blobPath(sum string) string {
+	return filepath.Join(s.baseDir, blobDir, sum[:2], sum)
+}
+
+// storeFile makes path a link to the blob holding data, writing the blob first
+// unless a sound one with the same checksum is already stored, and indexes
+// it. The caller holds s.blobMu.
+func (s *StorageServer) storeFile(e catalogEntry, path string, data []byte) error {
+	if err := s.storeBlob(e.Sha256, data); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := atomicfile.Link(s.blobPath(e.Sha256), path); err != nil {
+		return fmt.Errorf("failed to link %s: %w", e.Path, err)
+	}
+	// path shares the blob's inode, so its mtime is that of whichever write
+	// stored the blob first. Record when this file was written instead.
+	e.ModTime = time.Now()
+	freed, err := s.catalog.put(e)
+	if err != nil {
+		return err
+	}
+	s.removeBlob(freed)
+	return nil
+}
+
+// storeBlob writes the blob sum unless an intact copy is already stored and
+// none of the files that share it has been found corrupt. A blob that is
+// written again replaces the old one under every file that refers to it,
+// which repairs them all at once.
+func (s *StorageServer) storeBlob(sum string, data []byte) error {
+	blob := s.blobPath(sum)
+	paths, corrupt, err := s.catalog.blobRefs(sum)
+	if err != nil {
+		return err
+	}
+	if !corrupt {
+		ok, err := blobIntact(blob, sum, int64(len(data)))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if ok {
+			s.stats.dedupedWrites.Add(1)
+			return nil
+		}
+		if err == nil {
+			log.Printf("Blob %s does not match its checksum; rewriting it", sum)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(blob, data, 0644); err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", sum, err)
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	for _, p := range paths {
+		vid, fname, _ := strings.Cut(p, "/")
+		if err := atomicfile.Link(blob, s.videoPath(vid, fname)); err != nil {
+			return fmt.Errorf("failed to relink %s: %w", p, err)
+		}
+	}
+	log.Printf("Rewrote blob %s shared by %d files", sum, len(paths))
+	return s.catalog.clearCorrupt(sum)
+}
+
+// removeBlob deletes the blob sum, which no file refers to any more.
+func (s *StorageServer) removeBlob(sum string) {
+	if sum == "" {
+		return
+	}
+	blob := s.blobPath(sum)
+	if err := os.Remove(blob); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove blob %s: %v", sum, err)
+	}
+	os.Remove(filepath.Dir(blob))
+}
+
+// linkBlobs makes every indexed file a link to its blob. It moves stores
+// written before deduplication, and catalogs rebuilt from disk, to the blob
+// layout, merging identical files as it goes. Files whose contents no longer
+// match the catalog are left alone for the scrubber to report.
+func (s *StorageServer) linkBlobs() error {
+	after := ""
+	n := 0
+	for {
+		files, err := s.catalog.list("", after, scrubPageSize)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			e, err := s.catalog.get(f.Path)
+			if err != nil || e == nil {
+				return err
+			}
+			vid, fname, _ := strings.Cut(f.Path, "/")
+			err = s.linkBlob(s.videoPath(vid, fname), e.Sha256, e.Size)
+			if errors.Is(err, os.ErrNotExist) {
+				// The scrubber reports it missing.
+				continue
+			}
+			if errors.Is(err, errContentMismatch) {
+				log.Printf("Not moving %s to its blob: %v", f.Path, err)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to move %s to its blob: %w", f.Path, err)
+			}
+			n++
+		}
+		if len(files) < scrubPageSize {
+			break
+		}
+		after = files[len(files)-1].Path
+	}
+	log.Printf("Linked %d files to their blobs", n)
+	return nil
+}
+
+// errContentMismatch is returned when a file does not hold the contents its
+// checksum says.
+var errContentMismatch = errors.New("contents do not match the recorded checksum")
+
+// linkBlob makes the file at path, which must hold size bytes with checksum
+// sum, share the blob sum. The file becomes the blob if there is no such blob
+// yet or the stored one is not intact.
+func (s *StorageServer) linkBlob(path, sum string, size int64) error {
+	blob := s.blobPath(sum)
+	pi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	bi, err := os.Stat(blob)
+	if err == nil && os.SameFile(bi, pi) {
+		return nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if ok, err := blobIntact(path, sum, size); err != nil {
+		return err
+	} else if !ok {
+		return errContentMismatch
+	}
+	if bi == nil {
+		if err := os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+			return err
+		}
+		return os.Link(path, blob)
+	}
+	ok, err := blobIntact(blob, sum, size)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Printf("Blob %s does not match its checksum; replacing it with %s", sum, path)
+		return atomicfile.Link(path, blob)
+	}
+	return atomicfile.Link(blob, path)
+}
+
+// blobIntact reports whether the file at path holds size bytes with checksum
+// sum. The size is checked first so most damaged files are not read.
+func blobIntact(path, sum string, size int64) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if info.Size() != size {
+		return false, nil
+	}
+	got, err := scanFile(path, "", "")
+	if err != nil {
+		return false, err
+	}
+	return got.Sha256 == sum, nil
+}
+
+// sweepBlobs removes the blobs no file refers to, which a crash between
+// writing a blob and indexing its file can leave behind.
+func (s *StorageServer) sweepBlobs() (int, error) {
+	n := 0
+	err := filepath.WalkDir(filepath.Join(s.baseDir, blobDir), func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		used, err := s.catalog.hasBlob(d.Name())
+		if err != nil || used {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		os.Remove(filepath.Dir(path))
+		n++
+		return nil
+	})
+	return n, err
+}
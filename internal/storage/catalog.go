@@ -0,0 +1,402 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tritontube/internal/proto"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// catalogFile is the name of the index database inside the base directory.
+// Like every top-level file it is not part of the stored content.
+const catalogFile = ".catalog.db"
+
+// catalogEntry is the indexed record of one stored file.
+type catalogEntry struct {
+	Path    string
+	VideoId string
+	Size    int64
+	Sha256  string
+	ModTime time.Time
+}
+
+// catalog indexes the files stored under a base directory so listings and
+// stats do not have to walk it. It also counts the files that share each
+// blob, so a blob is deleted with the last file that refers to it.
+type catalog struct {
+	db *sql.DB
+	// unlinked is set when the blobs table was just created, so the files
+	// indexed may not be links to their blobs yet.
+	unlinked bool
+}
+
+// openCatalog opens the catalog at path. If the database did not exist yet it
+// is built from the files under baseDir.
+func openCatalog(path, baseDir string) (*catalog, error) {
+	_, statErr := os.Stat(path)
+	missing := errors.Is(statErr, os.ErrNotExist)
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open catalog: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	createTableQuery := `
+	CREATE TABLE IF NOT EXISTS files (
+		path TEXT PRIMARY KEY,
+		video_id TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		sha256 TEXT NOT NULL,
+		mtime INTEGER NOT NULL,
+		corrupt INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS files_video ON files (video_id);
+	CREATE INDEX IF NOT EXISTS files_sha256 ON files (sha256);
+	CREATE TABLE IF NOT EXISTS blobs (
+		sha256 TEXT PRIMARY KEY,
+		size INTEGER NOT NULL,
+		refs INTEGER NOT NULL
+	);`
+	var hadBlobs bool
+	err = db.QueryRow("SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'blobs'").Scan(&hadBlobs)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to query catalog: %w", err)
+	}
+	if _, err := db.Exec(createTableQuery); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create catalog table: %w", err)
+	}
+	// Catalogs created before checksums were verified lack the corrupt column.
+	_, err = db.Exec("ALTER TABLE files ADD COLUMN corrupt INTEGER NOT NULL DEFAULT 0")
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		db.Close()
+		return nil, fmt.Errorf("failed to upgrade catalog table: %w", err)
+	}
+	c := &catalog{db: db, unlinked: !hadBlobs}
+	if missing {
+		if err := c.rebuild(baseDir); err != nil {
+			db.Close()
+			os.Remove(path)
+			return nil, err
+		}
+	} else if !hadBlobs {
+		// Catalogs created before deduplication have no reference counts.
+		_, err := db.Exec("INSERT INTO blobs (sha256, size, refs) SELECT sha256, MAX(size), COUNT(*) FROM files GROUP BY sha256")
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to count blob references: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// rebuild indexes every videoId/filename file under baseDir.
+func (c *catalog) rebuild(baseDir string) error {
+	start := time.Now()
+	videos, err := os.ReadDir(baseDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", baseDir, err)
+	}
+	n := 0
+	for _, v := range videos {
+		if !v.IsDir() || strings.HasPrefix(v.Name(), ".") {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(baseDir, v.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", v.Name(), err)
+		}
+		for _, f := range files {
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+				continue
+			}
+			e, err := scanFile(filepath.Join(baseDir, v.Name(), f.Name()), v.Name(), f.Name())
+			if err != nil {
+				return err
+			}
+			if _, err := c.put(e); err != nil {
+				return err
+			}
+			n++
+		}
+	}
+	log.Printf("Rebuilt catalog of %d files from %s in %v", n, baseDir, time.Since(start))
+	return nil
+}
+
+// scanFile reads a stored file and returns its catalog entry.
+func scanFile(path, videoId, filename string) (catalogEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return catalogEntry{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return catalogEntry{}, err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return catalogEntry{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return catalogEntry{
+		Path:    videoId + "/" + filename,
+		VideoId: videoId,
+		Size:    info.Size(),
+		Sha256:  hex.EncodeToString(h.Sum(nil)),
+		ModTime: info.ModTime(),
+	}, nil
+}
+
+// put indexes e, replacing any earlier entry for its path and clearing its
+// corrupt mark. If that leaves the blob the path used to refer to unused, put
+// returns its checksum so the caller can delete it.
+func (c *catalog) put(e catalogEntry) (string, error) {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to index %s: %w", e.Path, err)
+	}
+	defer tx.Rollback()
+	old, err := pathBlob(tx, e.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to index %s: %w", e.Path, err)
+	}
+	_, err = tx.Exec("INSERT OR REPLACE INTO files (path, video_id, size, sha256, mtime) VALUES (?, ?, ?, ?, ?)",
+		e.Path, e.VideoId, e.Size, e.Sha256, e.ModTime.UnixNano())
+	if err != nil {
+		return "", fmt.Errorf("failed to index %s: %w", e.Path, err)
+	}
+	var freed string
+	if old != e.Sha256 {
+		_, err := tx.Exec("INSERT INTO blobs (sha256, size, refs) VALUES (?, ?, 1) ON CONFLICT (sha256) DO UPDATE SET refs = refs + 1",
+			e.Sha256, e.Size)
+		if err != nil {
+			return "", fmt.Errorf("failed to index %s: %w", e.Path, err)
+		}
+		if freed, err = unref(tx, old); err != nil {
+			return "", fmt.Errorf("failed to index %s: %w", e.Path, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to index %s: %w", e.Path, err)
+	}
+	return freed, nil
+}
+
+// remove unindexes path and, like put, returns the checksum of its blob if no
+// other file refers to it.
+func (c *catalog) remove(path string) (string, error) {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to unindex %s: %w", path, err)
+	}
+	defer tx.Rollback()
+	old, err := pathBlob(tx, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to unindex %s: %w", path, err)
+	}
+	if _, err := tx.Exec("DELETE FROM files WHERE path = ?", path); err != nil {
+		return "", fmt.Errorf("failed to unindex %s: %w", path, err)
+	}
+	freed, err := unref(tx, old)
+	if err != nil {
+		return "", fmt.Errorf("failed to unindex %s: %w", path, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to unindex %s: %w", path, err)
+	}
+	return freed, nil
+}
+
+// pathBlob returns the checksum path is indexed with, or "" if it is not.
+func pathBlob(tx *sql.Tx, path string) (string, error) {
+	var sum string
+	err := tx.QueryRow("SELECT sha256 FROM files WHERE path = ?", path).Scan(&sum)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return sum, err
+}
+
+// unref drops a reference to the blob sum and returns sum if that was the
+// last one.
+func unref(tx *sql.Tx, sum string) (string, error) {
+	if sum == "" {
+		return "", nil
+	}
+	if _, err := tx.Exec("UPDATE blobs SET refs = refs - 1 WHERE sha256 = ?", sum); err != nil {
+		return "", err
+	}
+	res, err := tx.Exec("DELETE FROM blobs WHERE sha256 = ? AND refs <= 0", sum)
+	if err != nil {
+		return "", err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return "", nil
+	}
+	return sum, nil
+}
+
+// blobRefs returns the paths indexed with checksum sum and whether any of them
+// has been found corrupt.
+func (c *catalog) blobRefs(sum string) (paths []string, corrupt bool, err error) {
+	rows, err := c.db.Query("SELECT path, corrupt FROM files WHERE sha256 = ? ORDER BY path", sum)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query catalog: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var path string
+		var bad bool
+		if err := rows.Scan(&path, &bad); err != nil {
+			return nil, false, fmt.Errorf("failed to scan row: %w", err)
+		}
+		paths = append(paths, path)
+		corrupt = corrupt || bad
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("row iteration error: %w", err)
+	}
+	return paths, corrupt, nil
+}
+
+// clearCorrupt unmarks every file with checksum sum, once its blob has been
+// written again.
+func (c *catalog) clearCorrupt(sum string) error {
+	if _, err := c.db.Exec("UPDATE files SET corrupt = 0 WHERE sha256 = ?", sum); err != nil {
+		return fmt.Errorf("failed to clear corrupt marks: %w", err)
+	}
+	return nil
+}
+
+// hasBlob reports whether any file refers to the blob sum.
+func (c *catalog) hasBlob(sum string) (bool, error) {
+	var n int
+	if err := c.db.QueryRow("SELECT COUNT(*) FROM blobs WHERE sha256 = ?", sum).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to query catalog: %w", err)
+	}
+	return n > 0, nil
+}
+
+// blobUsage returns how many distinct blobs the files refer to and their total
+// size, which is the space the files take on disk.
+func (c *catalog) blobUsage() (count, bytes int64, err error) {
+	err = c.db.QueryRow("SELECT COUNT(*), COALESCE(SUM(size), 0) FROM blobs").Scan(&count, &bytes)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query catalog: %w", err)
+	}
+	return count, bytes, nil
+}
+
+// get returns the entry for path, or nil if it is not indexed.
+func (c *catalog) get(path string) (*catalogEntry, error) {
+	e := &catalogEntry{}
+	var mtime int64
+	err := c.db.QueryRow("SELECT path, video_id, size, sha256, mtime FROM files WHERE path = ?", path).
+		Scan(&e.Path, &e.VideoId, &e.Size, &e.Sha256, &mtime)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query catalog: %w", err)
+	}
+	e.ModTime = time.Unix(0, mtime)
+	return e, nil
+}
+
+// markCorrupt records that the file at path no longer matches sha256. It does
+// nothing if the file has been rewritten with other contents since.
+func (c *catalog) markCorrupt(path, sha256 string) error {
+	if _, err := c.db.Exec("UPDATE files SET corrupt = 1 WHERE path = ? AND sha256 = ?", path, sha256); err != nil {
+		return fmt.Errorf("failed to mark %s corrupt: %w", path, err)
+	}
+	return nil
+}
+
+// corrupt returns the paths of the files marked corrupt, in path order.
+func (c *catalog) corrupt() ([]string, error) {
+	rows, err := c.db.Query("SELECT path FROM files WHERE corrupt = 1 ORDER BY path")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query catalog: %w", err)
+	}
+	defer rows.Close()
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return paths, nil
+}
+
+// list returns up to limit files whose paths start with prefix and sort after
+// after, in path order. A limit of 0 returns them all.
+func (c *catalog) list(prefix, after string, limit int) ([]*proto.FileInfo, error) {
+	query := "SELECT path, size, mtime, sha256 FROM files WHERE substr(path, 1, length(?)) = ? AND path > ? ORDER BY path"
+	args := []any{prefix, prefix, after}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query catalog: %w", err)
+	}
+	defer rows.Close()
+	var files []*proto.FileInfo
+	for rows.Next() {
+		f := &proto.FileInfo{}
+		var mtime int64
+		if err := rows.Scan(&f.Path, &f.Size, &mtime, &f.Sha256); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		f.ModTime = time.Unix(0, mtime).Unix()
+		files = append(files, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return files, nil
+}
+
+// usage returns the file count and bytes of every video, largest first.
+func (c *catalog) usage() ([]*proto.VideoUsage, error) {
+	rows, err := c.db.Query("SELECT video_id, COUNT(*), SUM(size) FROM files GROUP BY video_id ORDER BY SUM(size) DESC, video_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query catalog: %w", err)
+	}
+	defer rows.Close()
+	var videos []*proto.VideoUsage
+	for rows.Next() {
+		v := &proto.VideoUsage{}
+		if err := rows.Scan(&v.VideoId, &v.Files, &v.Bytes); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		videos = append(videos, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return videos, nil
+}
+
+func (c *catalog) Close() error {
+	return c.db.Close()
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package storage
+
+// diskUsage is not supported on this platform.
+func diskUsage(path string) (free, total int64) {
+	return 0, 0
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin
+
+package storage
+
+import "syscall"
+
+// diskUsage returns the free and total bytes of the file system holding path,
+// or zeros if it cannot be determined.
+func diskUsage(path string) (free, total int64) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return 0, 0
+	}
+	return int64(fs.Bavail) * int64(fs.Bsize), int64(fs.Blocks) * int64(fs.Bsize)
+}
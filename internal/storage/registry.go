@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Register publishes addr under prefix+addr in etcd, attached to a lease of
+// ttl seconds, and keeps the lease alive until ctx is cancelled. If the lease
+// is lost, for example after a long etcd outage, the node registers again.
+func Register(ctx context.Context, cli *clientv3.Client, prefix, addr string, ttl int64) error {
+	ch, err := register(ctx, cli, prefix+addr, addr, ttl)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for {
+			for range ch {
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Registration lease for %s lost, registering again", addr)
+			for {
+				ch, err = register(ctx, cli, prefix+addr, addr, ttl)
+				if err == nil {
+					break
+				}
+				log.Printf("Failed to register %s: %v", addr, err)
+				select {
+				case <-time.After(time.Second):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func register(ctx context.Context, cli *clientv3.Client, key, addr string, ttl int64) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	lease, err := cli.Grant(ctx, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant lease: %w", err)
+	}
+	if _, err := cli.Put(ctx, key, addr, clientv3.WithLease(lease.ID)); err != nil {
+		return nil, fmt.Errorf("failed to register %s: %w", key, err)
+	}
+	ch, err := cli.KeepAlive(ctx, lease.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to keep lease alive: %w", err)
+	}
+	log.Printf("Registered %s in etcd with lease %x (ttl %ds)", key, lease.ID, ttl)
+	return ch, nil
+}
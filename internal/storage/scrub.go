@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// scrubPageSize is how many catalog entries the scrubber reads at a time.
+const scrubPageSize = 1000
+
+// Scrub re-hashes every stored file once per interval until ctx is cancelled.
+// Files that are missing or no longer match their checksum are marked corrupt
+// and reported by GetStats for repair.
+func (s *StorageServer) Scrub(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		start := time.Now()
+		checked, corrupt, err := s.scrubOnce(ctx)
+		if err != nil {
+			log.Printf("Scrub stopped after %d files: %v", checked, err)
+			continue
+		}
+		s.lastScrub.Store(time.Now().Unix())
+		log.Printf("Scrubbed %d files in %v, %d corrupt", checked, time.Since(start), corrupt)
+	}
+}
+
+// scrubOnce makes one pass over the catalog.
+func (s *StorageServer) scrubOnce(ctx context.Context) (checked, corrupt int, err error) {
+	after := ""
+	for {
+		files, err := s.catalog.list("", after, scrubPageSize)
+		if err != nil {
+			return checked, corrupt, err
+		}
+		for _, f := range files {
+			if err := ctx.Err(); err != nil {
+				return checked, corrupt, err
+			}
+			ok, err := s.scrubFile(f.Path)
+			if err != nil {
+				return checked, corrupt, err
+			}
+			checked++
+			if !ok {
+				corrupt++
+			}
+		}
+		if len(files) < scrubPageSize {
+			return checked, corrupt, nil
+		}
+		after = files[len(files)-1].Path
+	}
+}
+
+// scrubFile re-hashes one file and reports whether it is intact.
+func (s *StorageServer) scrubFile(path string) (bool, error) {
+	e, err := s.catalog.get(path)
+	if err != nil || e == nil {
+		return true, err
+	}
+	vid, fname, _ := strings.Cut(path, "/")
+	got, err := scanFile(s.videoPath(vid, fname), vid, fname)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return true, err
+	}
+	if err == nil && got.Sha256 == e.Sha256 {
+		return true, nil
+	}
+	// Check again with writes held off, in case the file was rewritten or
+	// deleted while it was being hashed.
+	s.blobMu.Lock()
+	e, err = s.catalog.get(path)
+	if err == nil && e != nil {
+		got, err = scanFile(s.videoPath(vid, fname), vid, fname)
+	}
+	s.blobMu.Unlock()
+	if e == nil || (err != nil && !errors.Is(err, os.ErrNotExist)) {
+		return true, err
+	}
+	if err == nil && got.Sha256 == e.Sha256 {
+		return true, nil
+	}
+	if err != nil {
+		log.Printf("Scrub: %s is missing", path)
+	} else {
+		log.Printf("Scrub: %s is corrupt: expected %s, got %s", path, e.Sha256, got.Sha256)
+	}
+	return false, s.catalog.markCorrupt(path, e.Sha256)
+}
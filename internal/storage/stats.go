@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"sync/atomic"
+
+	"tritontube/internal/proto"
+)
+
+// requestStats counts the requests a node has served since it started.
+type requestStats struct {
+	reads, writes, deletes, lists, copies atomic.Int64
+	bytesRead, bytesWritten               atomic.Int64
+	errors                                atomic.Int64
+	checksumFailures                      atomic.Int64
+	dedupedWrites                         atomic.Int64
+}
+
+// failed counts err, if any, and returns it.
+func (st *requestStats) failed(err error) error {
+	if err != nil {
+		st.errors.Add(1)
+	}
+	return err
+}
+
+func (st *requestStats) toProto() *proto.RequestCounters {
+	return &proto.RequestCounters{
+		Reads:            st.reads.Load(),
+		Writes:           st.writes.Load(),
+		Deletes:          st.deletes.Load(),
+		Lists:            st.lists.Load(),
+		Copies:           st.copies.Load(),
+		BytesRead:        st.bytesRead.Load(),
+		BytesWritten:     st.bytesWritten.Load(),
+		Errors:           st.errors.Load(),
+		ChecksumFailures: st.checksumFailures.Load(),
+		DedupedWrites:    st.dedupedWrites.Load(),
+	}
+}
+
+// GetStats reports how much space the node uses, in total and per video, how
+// much of it deduplication saves, how much disk is free, the requests it has
+// served and the files found corrupt.
+func (s *StorageServer) GetStats(ctx context.Context, req *proto.GetStatsRequest) (*proto.GetStatsResponse, error) {
+	videos, err := s.catalog.usage()
+	if err != nil {
+		return nil, err
+	}
+	corrupt, err := s.catalog.corrupt()
+	if err != nil {
+		return nil, err
+	}
+	resp := &proto.GetStatsResponse{
+		Videos:       videos,
+		Requests:     s.stats.toProto(),
+		CorruptFiles: corrupt,
+		LastScrub:    s.lastScrub.Load(),
+	}
+	for _, v := range videos {
+		resp.FileCount += v.Files
+		resp.BytesUsed += v.Bytes
+	}
+	if resp.UniqueFiles, resp.StoredBytes, err = s.catalog.blobUsage(); err != nil {
+		return nil, err
+	}
+	resp.DedupRatio = dedupRatio(resp.BytesUsed, resp.StoredBytes)
+	resp.FreeBytes, resp.TotalBytes = diskUsage(s.baseDir)
+	return resp, nil
+}
+
+// dedupRatio is how many bytes of files each byte stored on disk holds.
+func dedupRatio(used, stored int64) float64 {
+	if stored == 0 {
+		return 1
+	}
+	return float64(used) / float64(stored)
+}
@@ -4,79 +4,333 @@ package storage
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 
+	"tritontube/internal/atomicfile"
+	"tritontube/internal/pathcheck"
 	"tritontube/internal/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 type StorageServer struct {
 	proto.UnimplementedVideoStorageServiceServer
 	baseDir string
+
+	peerCreds credentials.TransportCredentials
+	mu        sync.Mutex
+	peers     map[string]proto.VideoStorageServiceClient
+
+	catalog   *catalog
+	blobMu    sync.Mutex // serializes changes to blobs and their references
+	stats     requestStats
+	lastScrub atomic.Int64
 }
 
-func NewStorageServer(baseDir string) (*StorageServer, error) {
+// Option configures optional behaviour of a StorageServer.
+type Option func(*StorageServer)
+
+// WithPeerCredentials sets the transport credentials used to dial other
+// storage nodes for CopyTo. The default is an insecure connection.
+func WithPeerCredentials(creds credentials.TransportCredentials) Option {
+	return func(s *StorageServer) {
+		s.peerCreds = creds
+	}
+}
+
+func NewStorageServer(baseDir string, opts ...Option) (*StorageServer, error) {
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create base directory: %w", err)
 	}
-	return &StorageServer{baseDir: baseDir}, nil
+	s := &StorageServer{
+		baseDir:   baseDir,
+		peerCreds: insecure.NewCredentials(),
+		peers:     make(map[string]proto.VideoStorageServiceClient),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	n, err := atomicfile.Sweep(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove interrupted writes: %w", err)
+	}
+	if n > 0 {
+		log.Printf("Removed %d files left by interrupted writes", n)
+	}
+	c, err := openCatalog(filepath.Join(baseDir, catalogFile), baseDir)
+	if err != nil {
+		return nil, err
+	}
+	s.catalog = c
+	if c.unlinked {
+		if err := s.linkBlobs(); err != nil {
+			return nil, err
+		}
+	}
+	if n, err := s.sweepBlobs(); err != nil {
+		return nil, fmt.Errorf("failed to remove unused blobs: %w", err)
+	} else if n > 0 {
+		log.Printf("Removed %d blobs no file refers to", n)
+	}
+	return s, nil
 }
 
 func (s *StorageServer) videoPath(videoId string, filename string) string {
 	return filepath.Join(s.baseDir, videoId, filename)
 }
 
+// checkedPath validates a requested videoId and filename and returns the path
+// they name.
+func (s *StorageServer) checkedPath(videoId string, filename string) (string, error) {
+	if err := pathcheck.Validate(videoId, filename); err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	return s.videoPath(videoId, filename), nil
+}
+
 func (s *StorageServer) WriteFile(ctx context.Context, req *proto.WriteFileRequest) (*proto.WriteFileResponse, error) {
-	dir := filepath.Join(s.baseDir, req.GetVideoId())
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, err
+	s.stats.writes.Add(1)
+	path, err := s.checkedPath(req.GetVideoId(), req.GetFilename())
+	if err != nil {
+		return nil, s.stats.failed(err)
 	}
-	path := s.videoPath(req.GetVideoId(), req.GetFilename())
-	if err := ioutil.WriteFile(path, req.GetData(), 0644); err != nil {
-		return nil, err
+	s.blobMu.Lock()
+	err = s.storeFile(catalogEntry{
+		Path:    req.GetVideoId() + "/" + req.GetFilename(),
+		VideoId: req.GetVideoId(),
+		Size:    int64(len(req.GetData())),
+		Sha256:  checksum(req.GetData()),
+	}, path, req.GetData())
+	s.blobMu.Unlock()
+	if err != nil {
+		return nil, s.stats.failed(err)
 	}
+	s.stats.bytesWritten.Add(int64(len(req.GetData())))
 	return &proto.WriteFileResponse{}, nil
 }
 
 func (s *StorageServer) ReadFile(ctx context.Context, req *proto.ReadFileRequest) (*proto.ReadFileResponse, error) {
-	path := s.videoPath(req.GetVideoId(), req.GetFilename())
-	data, err := ioutil.ReadFile(path)
+	s.stats.reads.Add(1)
+	if _, err := s.checkedPath(req.GetVideoId(), req.GetFilename()); err != nil {
+		return nil, s.stats.failed(err)
+	}
+	data, err := s.readVerified(req.GetVideoId(), req.GetFilename())
 	if err != nil {
-		return nil, err
+		return nil, s.stats.failed(err)
 	}
+	s.stats.bytesRead.Add(int64(len(data)))
 	return &proto.ReadFileResponse{Data: data}, nil
 }
 
 func (s *StorageServer) DeleteFile(ctx context.Context, req *proto.DeleteFileRequest) (*proto.DeleteFileResponse, error) {
-	path := s.videoPath(req.GetVideoId(), req.GetFilename())
+	s.stats.deletes.Add(1)
+	path, err := s.checkedPath(req.GetVideoId(), req.GetFilename())
+	if err != nil {
+		return nil, s.stats.failed(err)
+	}
+	s.blobMu.Lock()
+	defer s.blobMu.Unlock()
 	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
-		return nil, err
+		return nil, s.stats.failed(err)
 	}
+	freed, err := s.catalog.remove(req.GetVideoId() + "/" + req.GetFilename())
+	if err != nil {
+		return nil, s.stats.failed(err)
+	}
+	s.removeBlob(freed)
 
 	os.Remove(filepath.Dir(path))
 	return &proto.DeleteFileResponse{}, nil
 }
 
+// ListFiles lists the files whose paths start with the request's prefix, in
+// path order, one page at a time.
 func (s *StorageServer) ListFiles(ctx context.Context, req *proto.ListFilesRequest) (*proto.ListFilesResponse, error) {
-	var paths []string
-	err := filepath.Walk(s.baseDir, func(path string, info os.FileInfo, err error) error {
+	s.stats.lists.Add(1)
+	resp, err := s.listPage(req.GetPrefix(), req.GetPageToken(), int(req.GetPageSize()))
+	if err != nil {
+		return nil, s.stats.failed(err)
+	}
+	return resp, nil
+}
+
+// StreamFiles sends the files ListFiles would list as a stream of pages.
+func (s *StorageServer) StreamFiles(req *proto.ListFilesRequest, stream proto.VideoStorageService_StreamFilesServer) error {
+	s.stats.lists.Add(1)
+	size := int(req.GetPageSize())
+	if size <= 0 {
+		size = defaultStreamPageSize
+	}
+	token := req.GetPageToken()
+	for {
+		resp, err := s.listPage(req.GetPrefix(), token, size)
 		if err != nil {
-			return err
+			return s.stats.failed(err)
+		}
+		if len(resp.Files) > 0 {
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
 		}
-		if info.IsDir() {
+		if resp.NextPageToken == "" {
 			return nil
 		}
-		rel, err := filepath.Rel(s.baseDir, path)
-		if err != nil {
-			return err
+		token = resp.NextPageToken
+	}
+}
+
+// defaultStreamPageSize is the page size StreamFiles uses if none is given.
+const defaultStreamPageSize = 1000
+
+// listPage returns up to size files, or all of them if size is 0, whose paths
+// start with prefix and sort after token. The page token is the last path
+// returned.
+func (s *StorageServer) listPage(prefix, token string, size int) (*proto.ListFilesResponse, error) {
+	limit := 0
+	if size > 0 {
+		limit = size + 1
+	}
+	files, err := s.catalog.list(prefix, token, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := &proto.ListFilesResponse{}
+	if size > 0 && len(files) > size {
+		files = files[:size]
+		resp.NextPageToken = files[size-1].Path
+	}
+	resp.Files = files
+	for _, f := range files {
+		resp.Paths = append(resp.Paths, f.Path)
+	}
+	return resp, nil
+}
+
+// CopyTo writes a local file to the target node and reports the size and
+// SHA-256 of what it sent.
+func (s *StorageServer) CopyTo(ctx context.Context, req *proto.CopyToRequest) (*proto.CopyToResponse, error) {
+	s.stats.copies.Add(1)
+	if _, err := s.checkedPath(req.GetVideoId(), req.GetFilename()); err != nil {
+		return nil, s.stats.failed(err)
+	}
+	data, err := s.readVerified(req.GetVideoId(), req.GetFilename())
+	if err != nil {
+		return nil, s.stats.failed(err)
+	}
+	peer, err := s.peer(req.GetTarget())
+	if err != nil {
+		return nil, s.stats.failed(err)
+	}
+	_, err = peer.WriteFile(ctx, &proto.WriteFileRequest{VideoId: req.GetVideoId(), Filename: req.GetFilename(), Data: data})
+	if err != nil {
+		return nil, s.stats.failed(fmt.Errorf("write to %s: %w", req.GetTarget(), err))
+	}
+	s.stats.bytesRead.Add(int64(len(data)))
+	return &proto.CopyToResponse{Size: int64(len(data)), Sha256: checksum(data)}, nil
+}
+
+// StatFile reports a file's size and SHA-256 from the catalog, re-reading the
+// file to check it first if asked to.
+func (s *StorageServer) StatFile(ctx context.Context, req *proto.StatFileRequest) (*proto.StatFileResponse, error) {
+	if _, err := s.checkedPath(req.GetVideoId(), req.GetFilename()); err != nil {
+		return nil, err
+	}
+	e, err := s.catalog.get(req.GetVideoId() + "/" + req.GetFilename())
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, status.Errorf(codes.NotFound, "%s/%s not found", req.GetVideoId(), req.GetFilename())
+	}
+	if req.GetVerify() {
+		if _, err := s.readVerified(req.GetVideoId(), req.GetFilename()); err != nil {
+			return nil, err
 		}
-		paths = append(paths, filepath.ToSlash(rel))
-		return nil
-	})
+	}
+	return &proto.StatFileResponse{Size: e.Size, Sha256: e.Sha256}, nil
+}
+
+// readVerified reads videoId/filename and checks it against the checksum
+// recorded when it was written. The catalog entry is looked up before the file
+// is opened. A write that lands during the read can still make the two
+// disagree, so a mismatch is confirmed by reading both again while holding
+// blobMu, which writes hold from linking the file until it is indexed. On a
+// real mismatch the file is marked corrupt and a DataLoss error returned so
+// that nothing serves or copies it. A missing file is reported as NotFound.
+func (s *StorageServer) readVerified(videoId, filename string) ([]byte, error) {
+	path := videoId + "/" + filename
+	e, data, err := s.readEntry(videoId, filename)
+	if err == nil && (e == nil || checksum(data) == e.Sha256) {
+		return data, nil
+	}
+	if err == nil {
+		s.blobMu.Lock()
+		e, data, err = s.readEntry(videoId, filename)
+		s.blobMu.Unlock()
+	}
+	if os.IsNotExist(err) {
+		if e != nil {
+			return nil, status.Errorf(codes.NotFound, "%s is catalogued but missing", path)
+		}
+		return nil, status.Errorf(codes.NotFound, "%s not found", path)
+	}
+	if err != nil || e == nil {
+		return data, err
+	}
+	if sum := checksum(data); sum != e.Sha256 {
+		s.stats.checksumFailures.Add(1)
+		log.Printf("Checksum mismatch for %s: expected %s, got %s", path, e.Sha256, sum)
+		if err := s.catalog.markCorrupt(path, e.Sha256); err != nil {
+			log.Printf("%v", err)
+		}
+		return nil, status.Errorf(codes.DataLoss, "%s is corrupt", path)
+	}
+	return data, nil
+}
+
+// readEntry returns the catalog entry of videoId/filename, or nil if it is not
+// indexed, and then the file's contents.
+func (s *StorageServer) readEntry(videoId, filename string) (*catalogEntry, []byte, error) {
+	e, err := s.catalog.get(videoId + "/" + filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := ioutil.ReadFile(s.videoPath(videoId, filename))
+	if err != nil {
+		return e, nil, err
+	}
+	return e, data, nil
+}
+
+// peer returns a client for another storage node, dialing it on first use.
+func (s *StorageServer) peer(addr string) (proto.VideoStorageServiceClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.peers[addr]; ok {
+		return c, nil
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(s.peerCreds))
 	if err != nil {
 		return nil, err
 	}
-	return &proto.ListFilesResponse{Paths: paths}, nil
+	c := proto.NewVideoStorageServiceClient(conn)
+	s.peers[addr] = c
+	return c, nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestServer(t *testing.T, dir string) *StorageServer {
+	t.Helper()
+	s, err := NewStorageServer(dir)
+	if err != nil {
+		t.Fatalf("NewStorageServer: %v", err)
+	}
+	t.Cleanup(func() { s.catalog.Close() })
+	return s
+}
+
+func writeFile(t *testing.T, s *StorageServer, videoId, filename, data string) {
+	t.Helper()
+	_, err := s.WriteFile(context.Background(), &proto.WriteFileRequest{VideoId: videoId, Filename: filename, Data: []byte(data)})
+	if err != nil {
+		t.Fatalf("WriteFile %s/%s: %v", videoId, filename, err)
+	}
+}
+
+func readFile(t *testing.T, s *StorageServer, videoId, filename string) string {
+	t.Helper()
+	resp, err := s.ReadFile(context.Background(), &proto.ReadFileRequest{VideoId: videoId, Filename: filename})
+	if err != nil {
+		t.Fatalf("ReadFile %s/%s: %v", videoId, filename, err)
+	}
+	return string(resp.GetData())
+}
+
+func TestInterruptedWriteKeepsOldContents(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestServer(t, dir)
+	writeFile(t, s, "video", "manifest.mpd", "old manifest")
+	s.catalog.Close()
+
+	// Simulate a crash part way through rewriting the file: the new blob only
+	// reached its temporary file, and a temporary link to the old blob was
+	// made but never renamed into place.
+	sum := checksum([]byte("old manifest"))
+	leftovers := []string{
+		filepath.Join(dir, blobDir, sum[:2], ".tmp-"+sum+"-123"),
+		filepath.Join(dir, "video", ".tmp-manifest.mpd-456"),
+	}
+	if err := os.WriteFile(leftovers[0], []byte("new man"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(s.blobPath(sum), leftovers[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	s = newTestServer(t, dir)
+	if got := readFile(t, s, "video", "manifest.mpd"); got != "old manifest" {
+		t.Errorf("ReadFile = %q, want %q", got, "old manifest")
+	}
+	for _, p := range leftovers {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("interrupted write %s was not removed at startup", p)
+		}
+	}
+	resp, err := s.ListFiles(context.Background(), &proto.ListFilesRequest{})
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	if len(resp.Paths) != 1 || resp.Paths[0] != "video/manifest.mpd" {
+		t.Errorf("ListFiles = %v, want [video/manifest.mpd]", resp.Paths)
+	}
+}
+
+func TestReadDuringRewriteIsNotCorrupt(t *testing.T) {
+	s := newTestServer(t, t.TempDir())
+	versions := []string{"first version of the manifest", "second, longer version of the manifest"}
+	writeFile(t, s, "video", "manifest.mpd", versions[0])
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 200; i++ {
+			_, err := s.WriteFile(context.Background(), &proto.WriteFileRequest{
+				VideoId: "video", Filename: "manifest.mpd", Data: []byte(versions[i%2]),
+			})
+			if err != nil {
+				t.Errorf("WriteFile: %v", err)
+				return
+			}
+		}
+	}()
+	for reading := true; reading; {
+		select {
+		case <-done:
+			reading = false
+		default:
+		}
+		resp, err := s.ReadFile(context.Background(), &proto.ReadFileRequest{VideoId: "video", Filename: "manifest.mpd"})
+		if err != nil {
+			t.Fatalf("ReadFile during rewrite: %v", err)
+		}
+		if got := string(resp.GetData()); got != versions[0] && got != versions[1] {
+			t.Fatalf("ReadFile = %q, want one of the written versions", got)
+		}
+	}
+
+	corrupt, err := s.catalog.corrupt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrupt) > 0 {
+		t.Errorf("files marked corrupt by concurrent reads: %v", corrupt)
+	}
+}
+
+func TestReadReportsCorruption(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestServer(t, dir)
+	writeFile(t, s, "video", "manifest.mpd", "intact manifest")
+
+	// Damage the stored bytes in place, as bit rot would.
+	if err := os.WriteFile(filepath.Join(dir, "video", "manifest.mpd"), []byte("intact manifesT"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := s.ReadFile(context.Background(), &proto.ReadFileRequest{VideoId: "video", Filename: "manifest.mpd"})
+	if status.Code(err) != codes.DataLoss {
+		t.Fatalf("ReadFile = %v, want DataLoss", err)
+	}
+	corrupt, err := s.catalog.corrupt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrupt) != 1 || corrupt[0] != "video/manifest.mpd" {
+		t.Errorf("corrupt files = %v, want [video/manifest.mpd]", corrupt)
+	}
+}
+
+func TestDedupedWriteRecordsItsOwnTime(t *testing.T) {
+	s := newTestServer(t, t.TempDir())
+	writeFile(t, s, "old", "chunk.m4s", "shared segment")
+	time.Sleep(20 * time.Millisecond)
+
+	before := time.Now()
+	writeFile(t, s, "new", "chunk.m4s", "shared segment")
+	e, err := s.catalog.get("new/chunk.m4s")
+	if err != nil || e == nil {
+		t.Fatalf("catalog entry for new/chunk.m4s: %v, %v", e, err)
+	}
+	// The file shares the older blob's inode, but it was written just now.
+	if e.ModTime.Before(before) {
+		t.Errorf("ModTime = %v, want no earlier than the write at %v", e.ModTime, before)
+	}
+}
+
+func TestMissingFileIsNotFound(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestServer(t, dir)
+	writeFile(t, s, "video", "manifest.mpd", "manifest")
+	// Lose the file behind the catalog's back.
+	if err := os.Remove(filepath.Join(dir, "video", "manifest.mpd")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := s.ReadFile(context.Background(), &proto.ReadFileRequest{VideoId: "video", Filename: "manifest.mpd"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("ReadFile of catalogued but missing file = %v, want NotFound", err)
+	}
+	_, err = s.CopyTo(context.Background(), &proto.CopyToRequest{VideoId: "video", Filename: "manifest.mpd", Target: "127.0.0.1:1"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("CopyTo of catalogued but missing file = %v, want NotFound", err)
+	}
+	_, err = s.StatFile(context.Background(), &proto.StatFileRequest{VideoId: "video", Filename: "manifest.mpd", Verify: true})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("StatFile of catalogued but missing file = %v, want NotFound", err)
+	}
+	_, err = s.ReadFile(context.Background(), &proto.ReadFileRequest{VideoId: "video", Filename: "other.m4s"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("ReadFile of unknown file = %v, want NotFound", err)
+	}
+}
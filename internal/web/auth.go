@@ -0,0 +1,230 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Admin roles. Operators can do everything viewers can.
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+)
+
+// adminMethodRoles lists the role each admin RPC needs. Operator methods
+// change cluster state and are audited. CollectGarbage and CheckConsistency
+// only read unless asked to delete or repair, and their handlers demand the
+// operator role for that.
+var adminMethodRoles = map[string]string{
+	"ListNodes":            RoleViewer,
+	"ListRebalances":       RoleViewer,
+	"GetRebalance":         RoleViewer,
+	"WatchRebalance":       RoleViewer,
+	"PlanMembershipChange": RoleViewer,
+	"CollectGarbage":       RoleViewer,
+	"CheckConsistency":     RoleViewer,
+	"AddNode":              RoleOperator,
+	"RemoveNode":           RoleOperator,
+	"DrainNode":            RoleOperator,
+	"DecommissionNode":     RoleOperator,
+	"UndrainNode":          RoleOperator,
+	"CancelRebalance":      RoleOperator,
+	"ResumeRebalance":      RoleOperator,
+}
+
+// AdminPrincipal is a caller known to the admin service.
+type AdminPrincipal struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// AdminAuth maps credentials to principals. Callers authenticate with a bearer
+// token or, when the admin port uses mutual TLS, with the common name of their
+// client certificate.
+type AdminAuth struct {
+	Tokens map[string]AdminPrincipal `json:"tokens"` // bearer token -> principal
+	Certs  map[string]string         `json:"certs"`  // certificate common name -> role
+}
+
+// LoadAdminAuth reads an AdminAuth from a JSON file such as
+//
+//	{"tokens": {"s3cret": {"name": "alice", "role": "operator"}},
+//	 "certs": {"tritontube": "operator"}}
+func LoadAdminAuth(path string) (*AdminAuth, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &AdminAuth{}
+	if err := json.Unmarshal(data, a); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for token, p := range a.Tokens {
+		if p.Role != RoleViewer && p.Role != RoleOperator {
+			return nil, fmt.Errorf("token for %s has unknown role %q", p.Name, p.Role)
+		}
+		if token == "" {
+			return nil, fmt.Errorf("empty token for %s", p.Name)
+		}
+	}
+	for cn, role := range a.Certs {
+		if role != RoleViewer && role != RoleOperator {
+			return nil, fmt.Errorf("certificate %s has unknown role %q", cn, role)
+		}
+	}
+	return a, nil
+}
+
+// WithAdminAuth requires admin callers to authenticate and checks their role
+// against each RPC. Without it every caller is treated as an operator.
+func WithAdminAuth(a *AdminAuth) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.adminAuth = a
+	}
+}
+
+// WithAuditLog writes an entry for every state-changing admin call to w. The
+// default is the standard logger.
+func WithAuditLog(w io.Writer) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.audit = log.New(w, "", log.LstdFlags)
+	}
+}
+
+// authenticate returns the principal making the call in ctx.
+func (a *AdminAuth) authenticate(ctx context.Context) (AdminPrincipal, error) {
+	if a == nil {
+		return AdminPrincipal{Name: "anonymous", Role: RoleOperator}, nil
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get("authorization") {
+			token, ok := strings.CutPrefix(v, "Bearer ")
+			if !ok {
+				continue
+			}
+			if p, ok := a.Tokens[token]; ok {
+				return p, nil
+			}
+			return AdminPrincipal{}, status.Error(codes.Unauthenticated, "invalid token")
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+			cn := info.State.VerifiedChains[0][0].Subject.CommonName
+			if role, ok := a.Certs[cn]; ok {
+				return AdminPrincipal{Name: "cert:" + cn, Role: role}, nil
+			}
+		}
+	}
+	return AdminPrincipal{}, status.Error(codes.Unauthenticated, "missing or unknown credentials")
+}
+
+func allowed(role, need string) bool {
+	return role == RoleOperator || role == need
+}
+
+// adminCall is the authorized caller of an admin RPC. It travels in the
+// call's context so that a handler can demand the operator role for requests
+// that change state.
+type adminCall struct {
+	principal AdminPrincipal
+	need      string
+}
+
+type adminCallKey struct{}
+
+// authorize authenticates the caller of method and checks its role. Calls
+// forwarded to the leader carry the caller's token along.
+func (s *NetworkVideoContentService) authorize(ctx context.Context, fullMethod string) (context.Context, *adminCall, error) {
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	need, ok := adminMethodRoles[method]
+	if !ok {
+		need = RoleOperator
+	}
+	p, err := s.adminAuth.authenticate(ctx)
+	if err != nil {
+		s.audit.Printf("AUDIT: denied %s: %v", method, err)
+		return ctx, nil, err
+	}
+	if !allowed(p.Role, need) {
+		s.audit.Printf("AUDIT: denied %s for %s (%s)", method, p.Name, p.Role)
+		return ctx, nil, status.Errorf(codes.PermissionDenied, "%s needs the %s role", method, need)
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get("authorization") {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", v)
+		}
+	}
+	call := &adminCall{principal: p, need: need}
+	return context.WithValue(ctx, adminCallKey{}, call), call, nil
+}
+
+// requireOperator fails unless the caller in ctx is an operator, and has the
+// call audited like an operator method. what describes the request, as in
+// "CheckConsistency with repair". Calls made in-process, not through the
+// admin server, are allowed.
+func (s *NetworkVideoContentService) requireOperator(ctx context.Context, what string) error {
+	call, ok := ctx.Value(adminCallKey{}).(*adminCall)
+	if !ok {
+		return nil
+	}
+	if p := call.principal; !allowed(p.Role, RoleOperator) {
+		s.audit.Printf("AUDIT: denied %s for %s (%s)", what, p.Name, p.Role)
+		return status.Errorf(codes.PermissionDenied, "%s needs the %s role", what, RoleOperator)
+	}
+	call.need = RoleOperator
+	return nil
+}
+
+func (s *NetworkVideoContentService) adminUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, call, err := s.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := handler(ctx, req)
+	if p := call.principal; call.need == RoleOperator {
+		s.audit.Printf("AUDIT: %s (%s) called %s %v: %s", p.Name, p.Role, info.FullMethod, req, auditResult(err))
+	}
+	return resp, err
+}
+
+func (s *NetworkVideoContentService) adminStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, call, err := s.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	err = handler(srv, &authedStream{ServerStream: ss, ctx: ctx})
+	if p := call.principal; call.need == RoleOperator {
+		s.audit.Printf("AUDIT: %s (%s) called %s: %s", p.Name, p.Role, info.FullMethod, auditResult(err))
+	}
+	return err
+}
+
+// authedStream overrides the context of a server stream.
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context {
+	return s.ctx
+}
+
+func auditResult(err error) string {
+	if err != nil {
+		return "failed: " + err.Error()
+	}
+	return "ok"
+}
@@ -0,0 +1,75 @@
+package web
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestViewerMayOnlyCheckAndDryRun(t *testing.T) {
+	adminAddr := freeAddr(t)
+	auth := &AdminAuth{Tokens: map[string]AdminPrincipal{
+		"view": {Name: "viewer", Role: RoleViewer},
+		"op":   {Name: "operator", Role: RoleOperator},
+	}}
+	if _, err := NewNetworkVideoContentService(adminAddr, []string{startStorageNode(t)}, WithAdminAuth(auth)); err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	conn, err := grpc.NewClient(adminAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := proto.NewVideoContentAdminServiceClient(conn)
+
+	check := func(token string, repair bool) error {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+		stream, err := client.CheckConsistency(ctx, &proto.CheckConsistencyRequest{VideoIds: []string{"video"}, Repair: repair})
+		if err != nil {
+			return err
+		}
+		for {
+			if _, err := stream.Recv(); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+	collect := func(token string, dryRun bool) error {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+		_, err := client.CollectGarbage(ctx, &proto.CollectGarbageRequest{DryRun: dryRun})
+		return err
+	}
+
+	tests := []struct {
+		name       string
+		call       func() error
+		wantDenied bool
+	}{
+		{"viewer checks", func() error { return check("view", false) }, false},
+		{"viewer repairs", func() error { return check("view", true) }, true},
+		{"operator repairs", func() error { return check("op", true) }, false},
+		// Without a metadata service the call fails later, but not for its role.
+		{"viewer dry run", func() error { return collect("view", true) }, false},
+		{"viewer deletes", func() error { return collect("view", false) }, true},
+		{"operator deletes", func() error { return collect("op", false) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if denied := status.Code(err) == codes.PermissionDenied; denied != tt.wantDenied {
+				t.Errorf("err = %v, want denied %v", err, tt.wantDenied)
+			}
+		})
+	}
+}
@@ -0,0 +1,481 @@
+package web
+
+import (
+	"container/heap"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"expvar"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CachePolicy selects which files a full cache tier evicts first.
+type CachePolicy string
+
+const (
+	// CacheLRU evicts the file read least recently.
+	CacheLRU CachePolicy = "lru"
+	// CacheLFU evicts the file read least often, keeping popular videos
+	// cached through bursts of one-off reads.
+	CacheLFU CachePolicy = "lfu"
+)
+
+// ParseCachePolicy converts a command-line value into a CachePolicy.
+func ParseCachePolicy(s string) (CachePolicy, error) {
+	switch p := CachePolicy(s); p {
+	case CacheLRU, CacheLFU:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown cache policy %q (want lru or lfu)", s)
+}
+
+// Counters for the content cache, published at /debug/vars.
+var (
+	cacheHits      = expvar.NewInt("content_cache_hits")
+	cacheDiskHits  = expvar.NewInt("content_cache_disk_hits")
+	cacheMisses    = expvar.NewInt("content_cache_misses")
+	cacheCoalesced = expvar.NewInt("content_cache_coalesced")
+	cacheEvictions = expvar.NewInt("content_cache_evictions")
+	cacheMemBytes  = expvar.NewInt("content_cache_memory_bytes")
+	cacheDiskBytes = expvar.NewInt("content_cache_disk_bytes")
+)
+
+// The hit rate is the share of reads served from either tier. Reads that
+// waited for another read's miss are counted apart, as neither hits nor
+// misses of their own.
+func init() {
+	expvar.Publish("content_cache_hit_rate", expvar.Func(func() any {
+		hits := cacheHits.Value() + cacheDiskHits.Value()
+		if total := hits + cacheMisses.Value(); total > 0 {
+			return float64(hits) / float64(total)
+		}
+		return 0.0
+	}))
+}
+
+// DefaultCacheTTL bounds how long a frontend serves a cached file that
+// another frontend has since rewritten or deleted. Writes and deletes made
+// through this frontend invalidate its cache at once.
+const DefaultCacheTTL = 5 * time.Minute
+
+// cacheFilePrefix starts the name of every file the disk tier writes, so
+// files left by an earlier run can be told apart from anything else in the
+// directory.
+const cacheFilePrefix = "seg-"
+
+// CachedVideoContentService serves reads from a size-bounded memory tier and
+// an optional local-disk tier in front of another VideoContentService.
+// Concurrent misses for the same file share one backend read, and writes and
+// deletes invalidate what they change. Changes made through other frontends
+// are not seen until the cached copy expires, after the cache's TTL.
+type CachedVideoContentService struct {
+	backend VideoContentService
+	policy  CachePolicy
+	ttl     time.Duration // 0 keeps files until evicted
+
+	diskDir      string
+	diskMaxBytes int64
+	diskSeq      atomic.Uint64
+
+	mu      sync.Mutex
+	mem     *cacheTier
+	disk    *cacheTier // nil without a disk tier
+	flights map[string]*cacheFlight
+}
+
+var _ VideoContentService = (*CachedVideoContentService)(nil)
+
+// CacheOption configures optional behaviour of a CachedVideoContentService.
+type CacheOption func(*CachedVideoContentService)
+
+// WithCachePolicy sets how both tiers choose what to evict. The default is
+// CacheLRU.
+func WithCachePolicy(policy CachePolicy) CacheOption {
+	return func(c *CachedVideoContentService) {
+		c.policy = policy
+	}
+}
+
+// WithCacheTTL sets how long a file is served from the cache before it is read
+// from the backend again. The default is DefaultCacheTTL; zero keeps files
+// until they are evicted, which is only safe if this frontend is the only one
+// writing and deleting content.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *CachedVideoContentService) {
+		c.ttl = ttl
+	}
+}
+
+// WithDiskCache adds a second tier of up to maxBytes in dir, for files that
+// no longer fit in memory. Files cached there by an earlier run are removed,
+// since the content may have changed while this frontend was down.
+func WithDiskCache(dir string, maxBytes int64) CacheOption {
+	return func(c *CachedVideoContentService) {
+		c.diskDir = dir
+		c.diskMaxBytes = maxBytes
+	}
+}
+
+// NewCachedVideoContentService caches up to memBytes of the files read from
+// backend in memory.
+func NewCachedVideoContentService(backend VideoContentService, memBytes int64, opts ...CacheOption) (*CachedVideoContentService, error) {
+	c := &CachedVideoContentService{
+		backend: backend,
+		policy:  CacheLRU,
+		ttl:     DefaultCacheTTL,
+		flights: make(map[string]*cacheFlight),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.mem = newCacheTier(memBytes, c.policy)
+	if c.diskDir != "" && c.diskMaxBytes > 0 {
+		if err := os.MkdirAll(c.diskDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+		stale, err := filepath.Glob(filepath.Join(c.diskDir, cacheFilePrefix+"*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range stale {
+			if err := os.Remove(f); err != nil {
+				return nil, fmt.Errorf("failed to clear cache directory: %w", err)
+			}
+		}
+		c.disk = newCacheTier(c.diskMaxBytes, c.policy)
+	}
+	log.Printf("DEBUG: Caching content with %s eviction and a TTL of %v, %d bytes in memory, %d bytes on disk in %q",
+		c.policy, c.ttl, memBytes, c.diskMaxBytes, c.diskDir)
+	return c, nil
+}
+
+// cacheFlight is a backend read that concurrent misses for the same file wait
+// for instead of issuing their own.
+type cacheFlight struct {
+	done chan struct{}
+	data []byte
+	err  error
+	// readAt is when data was read from the backend, from which its TTL runs.
+	readAt time.Time
+	// stale is set if the file was written or deleted while the read was in
+	// flight, so its result must not be cached.
+	stale bool
+}
+
+// Read returns a file from the cache, reading it from the backend on a miss.
+// The returned data is shared with the cache and must not be modified.
+func (c *CachedVideoContentService) Read(videoId string, filename string) ([]byte, error) {
+	key := videoId + "/" + filename
+	c.mu.Lock()
+	if e := c.get(c.mem, key); e != nil {
+		c.mu.Unlock()
+		cacheHits.Add(1)
+		return e.data, nil
+	}
+	if f, ok := c.flights[key]; ok {
+		c.mu.Unlock()
+		cacheCoalesced.Add(1)
+		<-f.done
+		return f.data, f.err
+	}
+	f := &cacheFlight{done: make(chan struct{})}
+	c.flights[key] = f
+	var onDisk *cacheEntry
+	if c.disk != nil {
+		onDisk = c.get(c.disk, key)
+	}
+	c.mu.Unlock()
+
+	defer close(f.done)
+	if onDisk != nil {
+		data, err := os.ReadFile(onDisk.path)
+		if err == nil {
+			cacheDiskHits.Add(1)
+			f.data, f.readAt = data, onDisk.readAt
+			c.finish(key, f, nil)
+			return data, nil
+		}
+		log.Printf("DEBUG: Failed to read cached %s: %v", key, err)
+	}
+
+	cacheMisses.Add(1)
+	f.readAt = time.Now()
+	f.data, f.err = c.backend.Read(videoId, filename)
+	if f.err != nil {
+		c.finish(key, f, nil)
+		return nil, f.err
+	}
+	c.finish(key, f, c.writeDisk(key, f.data, f.readAt))
+	return f.data, nil
+}
+
+// get returns t's entry for key, counting it as read, or nil if there is none
+// or it has expired. The caller holds c.mu.
+func (c *CachedVideoContentService) get(t *cacheTier, key string) *cacheEntry {
+	e := t.get(key)
+	if e == nil || c.ttl <= 0 || time.Since(e.readAt) < c.ttl {
+		return e
+	}
+	t.removeEntry(e)
+	c.dropped(t, []*cacheEntry{e})
+	return nil
+}
+
+// writeDisk copies a file read from the backend to the disk tier and returns
+// its entry, or nil if there is no disk tier or the file does not fit.
+func (c *CachedVideoContentService) writeDisk(key string, data []byte, readAt time.Time) *cacheEntry {
+	if c.disk == nil || int64(len(data)) > c.diskMaxBytes {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(key))
+	name := cacheFilePrefix + hex.EncodeToString(sum[:8]) + "-" + strconv.FormatUint(c.diskSeq.Add(1), 10)
+	e := &cacheEntry{key: key, size: int64(len(data)), path: filepath.Join(c.diskDir, name), readAt: readAt}
+	if err := os.WriteFile(e.path, data, 0644); err != nil {
+		log.Printf("DEBUG: Failed to cache %s on disk: %v", key, err)
+		os.Remove(e.path)
+		return nil
+	}
+	return e
+}
+
+// finish ends a flight, caching its result in memory, and onDisk in the disk
+// tier, unless the file changed while it was being read.
+func (c *CachedVideoContentService) finish(key string, f *cacheFlight, onDisk *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.flights[key] == f {
+		delete(c.flights, key)
+	}
+	if f.err != nil || f.stale {
+		if onDisk != nil {
+			os.Remove(onDisk.path)
+		}
+		return
+	}
+	c.addTier(c.mem, &cacheEntry{key: key, size: int64(len(f.data)), data: f.data, readAt: f.readAt})
+	if onDisk != nil {
+		c.addTier(c.disk, onDisk)
+	}
+}
+
+// addTier adds e to t, removing what that evicts. The caller holds c.mu.
+func (c *CachedVideoContentService) addTier(t *cacheTier, e *cacheEntry) {
+	added, evicted := t.add(e)
+	if !added && e.path != "" {
+		os.Remove(e.path)
+	}
+	cacheEvictions.Add(int64(len(evicted)))
+	c.dropped(t, evicted)
+	c.account(t, e.size, added)
+}
+
+// dropped releases the entries removed from t. The caller holds c.mu.
+func (c *CachedVideoContentService) dropped(t *cacheTier, entries []*cacheEntry) {
+	for _, e := range entries {
+		if e.path != "" {
+			os.Remove(e.path)
+		}
+		c.account(t, -e.size, true)
+	}
+}
+
+func (c *CachedVideoContentService) account(t *cacheTier, delta int64, changed bool) {
+	if !changed {
+		return
+	}
+	if t == c.mem {
+		cacheMemBytes.Add(delta)
+	} else {
+		cacheDiskBytes.Add(delta)
+	}
+}
+
+// Write writes through to the backend and drops any cached copy of the file.
+func (c *CachedVideoContentService) Write(videoId string, filename string, data []byte) error {
+	err := c.backend.Write(videoId, filename, data)
+	key := videoId + "/" + filename
+	c.invalidate(func(k string) bool { return k == key })
+	return err
+}
+
+// Delete deletes the video from the backend and drops every cached file of it.
+func (c *CachedVideoContentService) Delete(videoId string) error {
+	err := c.backend.Delete(videoId)
+	prefix := videoId + "/"
+	c.invalidate(func(k string) bool { return strings.HasPrefix(k, prefix) })
+	return err
+}
+
+// invalidate drops the cached files whose keys match, and keeps reads of them
+// that are in flight from caching what they read.
+func (c *CachedVideoContentService) invalidate(match func(key string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, f := range c.flights {
+		if match(key) {
+			f.stale = true
+			delete(c.flights, key)
+		}
+	}
+	c.dropped(c.mem, c.mem.removeMatching(match))
+	if c.disk != nil {
+		c.dropped(c.disk, c.disk.removeMatching(match))
+	}
+}
+
+// cacheEntry is one cached file: its contents in the memory tier, or the file
+// holding them in the disk tier.
+type cacheEntry struct {
+	key    string
+	size   int64
+	data   []byte
+	path   string
+	readAt time.Time // when the contents were read from the backend
+
+	elem  *list.Element // position in an LRU list
+	freq  int           // reads, for LFU
+	tick  uint64        // last read, breaking LFU ties
+	index int           // position in an LFU heap
+}
+
+// evictionPolicy orders a tier's entries for eviction.
+type evictionPolicy interface {
+	add(e *cacheEntry)
+	touch(e *cacheEntry)
+	remove(e *cacheEntry)
+	victim() *cacheEntry
+}
+
+// cacheTier is a size-bounded set of cached files. It is not safe for
+// concurrent use.
+type cacheTier struct {
+	entries  map[string]*cacheEntry
+	policy   evictionPolicy
+	size     int64
+	maxBytes int64
+}
+
+func newCacheTier(maxBytes int64, policy CachePolicy) *cacheTier {
+	t := &cacheTier{entries: make(map[string]*cacheEntry), maxBytes: maxBytes}
+	if policy == CacheLFU {
+		t.policy = &lfuPolicy{}
+	} else {
+		t.policy = &lruPolicy{order: list.New()}
+	}
+	return t
+}
+
+// get returns the entry for key, counting it as read, or nil.
+func (t *cacheTier) get(key string) *cacheEntry {
+	e := t.entries[key]
+	if e != nil {
+		t.policy.touch(e)
+	}
+	return e
+}
+
+// add inserts e, replacing any entry for its key, and evicts entries until
+// the tier fits. A file larger than the whole tier is not added.
+func (t *cacheTier) add(e *cacheEntry) (added bool, evicted []*cacheEntry) {
+	if e.size > t.maxBytes {
+		return false, nil
+	}
+	if old := t.entries[e.key]; old != nil {
+		t.removeEntry(old)
+		evicted = append(evicted, old)
+	}
+	for t.size+e.size > t.maxBytes {
+		v := t.policy.victim()
+		t.removeEntry(v)
+		evicted = append(evicted, v)
+	}
+	t.entries[e.key] = e
+	t.size += e.size
+	t.policy.add(e)
+	return true, evicted
+}
+
+// removeMatching removes and returns the entries whose keys match.
+func (t *cacheTier) removeMatching(match func(key string) bool) []*cacheEntry {
+	var removed []*cacheEntry
+	for key, e := range t.entries {
+		if match(key) {
+			t.removeEntry(e)
+			removed = append(removed, e)
+		}
+	}
+	return removed
+}
+
+func (t *cacheTier) removeEntry(e *cacheEntry) {
+	delete(t.entries, e.key)
+	t.size -= e.size
+	t.policy.remove(e)
+}
+
+// lruPolicy keeps entries in a list, most recently read first.
+type lruPolicy struct {
+	order *list.List
+}
+
+func (p *lruPolicy) add(e *cacheEntry)    { e.elem = p.order.PushFront(e) }
+func (p *lruPolicy) touch(e *cacheEntry)  { p.order.MoveToFront(e.elem) }
+func (p *lruPolicy) remove(e *cacheEntry) { p.order.Remove(e.elem) }
+func (p *lruPolicy) victim() *cacheEntry  { return p.order.Back().Value.(*cacheEntry) }
+
+// lfuPolicy keeps entries in a heap, least often read first, and among those
+// least recently read first.
+type lfuPolicy struct {
+	entries lfuHeap
+	clock   uint64
+}
+
+func (p *lfuPolicy) add(e *cacheEntry) {
+	p.clock++
+	e.freq, e.tick = 1, p.clock
+	heap.Push(&p.entries, e)
+}
+
+func (p *lfuPolicy) touch(e *cacheEntry) {
+	p.clock++
+	e.freq++
+	e.tick = p.clock
+	heap.Fix(&p.entries, e.index)
+}
+
+func (p *lfuPolicy) remove(e *cacheEntry) { heap.Remove(&p.entries, e.index) }
+func (p *lfuPolicy) victim() *cacheEntry  { return p.entries[0] }
+
+type lfuHeap []*cacheEntry
+
+func (h lfuHeap) Len() int { return len(h) }
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].tick < h[j].tick
+}
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *lfuHeap) Push(x any) {
+	e := x.(*cacheEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *lfuHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,302 @@
+package web
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeContent is an in-memory VideoContentService that counts reads and can
+// hold them until released.
+type fakeContent struct {
+	mu      sync.Mutex
+	files   map[string][]byte
+	reads   map[string]int
+	hold    chan struct{} // reads wait for it to close, if set
+	started chan string   // receives the key of every read, if set
+}
+
+func newFakeContent() *fakeContent {
+	return &fakeContent{files: make(map[string][]byte), reads: make(map[string]int)}
+}
+
+func (f *fakeContent) Write(videoId, filename string, data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[videoId+"/"+filename] = data
+	return nil
+}
+
+func (f *fakeContent) Read(videoId, filename string) ([]byte, error) {
+	key := videoId + "/" + filename
+	f.mu.Lock()
+	f.reads[key]++
+	data, ok := f.files[key]
+	hold, started := f.hold, f.started
+	f.mu.Unlock()
+	if started != nil {
+		started <- key
+	}
+	if hold != nil {
+		<-hold
+	}
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return data, nil
+}
+
+func (f *fakeContent) Delete(videoId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key := range f.files {
+		if strings.HasPrefix(key, videoId+"/") {
+			delete(f.files, key)
+		}
+	}
+	return nil
+}
+
+func (f *fakeContent) readsOf(key string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reads[key]
+}
+
+func newTestCache(t *testing.T, backend VideoContentService, memBytes int64, opts ...CacheOption) *CachedVideoContentService {
+	t.Helper()
+	c, err := NewCachedVideoContentService(backend, memBytes, opts...)
+	if err != nil {
+		t.Fatalf("NewCachedVideoContentService: %v", err)
+	}
+	return c
+}
+
+func readString(t *testing.T, c *CachedVideoContentService, videoId, filename string) string {
+	t.Helper()
+	data, err := c.Read(videoId, filename)
+	if err != nil {
+		t.Fatalf("Read %s/%s: %v", videoId, filename, err)
+	}
+	return string(data)
+}
+
+func TestCacheCoalescesConcurrentMisses(t *testing.T) {
+	backend := newFakeContent()
+	backend.Write("video", "manifest.mpd", []byte("manifest"))
+	backend.hold = make(chan struct{})
+	c := newTestCache(t, backend, 1<<20)
+
+	hits, misses, coalesced := cacheHits.Value(), cacheMisses.Value(), cacheCoalesced.Value()
+	const readers = 5
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if data, err := c.Read("video", "manifest.mpd"); err != nil || string(data) != "manifest" {
+				t.Errorf("Read = %q, %v", data, err)
+			}
+		}()
+	}
+	waitFor(t, "the readers to wait for one backend read", func() bool {
+		return cacheCoalesced.Value()-coalesced == readers-1
+	})
+	close(backend.hold)
+	wg.Wait()
+
+	if got := backend.readsOf("video/manifest.mpd"); got != 1 {
+		t.Errorf("backend reads = %d, want 1", got)
+	}
+	if got := cacheMisses.Value() - misses; got != 1 {
+		t.Errorf("misses = %d, want 1", got)
+	}
+	if got := cacheHits.Value() - hits; got != 0 {
+		t.Errorf("hits = %d, want coalesced reads not counted as hits", got)
+	}
+	readString(t, c, "video", "manifest.mpd")
+	if got := cacheHits.Value() - hits; got != 1 {
+		t.Errorf("hits after a cached read = %d, want 1", got)
+	}
+}
+
+func TestCacheDoesNotKeepFillRacingChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *CachedVideoContentService)
+		want   string
+	}{
+		{"write", func(c *CachedVideoContentService) { c.Write("video", "manifest.mpd", []byte("new")) }, "new"},
+		{"delete", func(c *CachedVideoContentService) {
+			c.Delete("video")
+			c.backend.Write("video", "manifest.mpd", []byte("reuploaded"))
+		}, "reuploaded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newFakeContent()
+			backend.Write("video", "manifest.mpd", []byte("old"))
+			backend.hold = make(chan struct{})
+			backend.started = make(chan string, 1)
+			c := newTestCache(t, backend, 1<<20)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				c.Read("video", "manifest.mpd")
+			}()
+			<-backend.started
+			// The file changes while the old contents are being read.
+			tt.change(c)
+			backend.mu.Lock()
+			hold := backend.hold
+			backend.hold, backend.started = nil, nil
+			backend.mu.Unlock()
+			close(hold)
+			<-done
+
+			if got := readString(t, c, "video", "manifest.mpd"); got != tt.want {
+				t.Errorf("Read after %s = %q, want %q", tt.name, got, tt.want)
+			}
+			if got := backend.readsOf("video/manifest.mpd"); got != 2 {
+				t.Errorf("backend reads = %d, want the racing fill not cached", got)
+			}
+		})
+	}
+}
+
+func TestCacheEvictionOrder(t *testing.T) {
+	tests := []struct {
+		policy  CachePolicy
+		evicted string
+	}{
+		// a was read most often but least recently.
+		{CacheLRU, "a"},
+		// c was read least often.
+		{CacheLFU, "c"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			backend := newFakeContent()
+			for _, name := range []string{"a", "b", "c", "d"} {
+				backend.Write("video", name, []byte("0123456789"))
+			}
+			c := newTestCache(t, backend, 30, WithCachePolicy(tt.policy))
+			for _, name := range []string{"a", "a", "a", "b", "c", "b"} {
+				readString(t, c, "video", name)
+			}
+			evictions := cacheEvictions.Value()
+			readString(t, c, "video", "d")
+			if got := cacheEvictions.Value() - evictions; got != 1 {
+				t.Errorf("evictions = %d, want 1", got)
+			}
+
+			for _, name := range []string{"a", "b", "c", "d"} {
+				before := backend.readsOf("video/" + name)
+				readString(t, c, "video", name)
+				refetched := backend.readsOf("video/"+name) > before
+				if refetched != (name == tt.evicted) {
+					t.Errorf("%s read from the backend again = %v, want only %s evicted", name, refetched, tt.evicted)
+				}
+				if refetched {
+					// Reading it back evicted something else; stop here.
+					break
+				}
+			}
+		})
+	}
+}
+
+func cacheFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, cacheFilePrefix+"*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestCacheDiskTier(t *testing.T) {
+	dir := t.TempDir()
+	// Files left by an earlier run are removed; anything else is not.
+	leftover := filepath.Join(dir, cacheFilePrefix+"old")
+	other := filepath.Join(dir, "notes.txt")
+	for _, f := range []string{leftover, other} {
+		if err := os.WriteFile(f, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backend := newFakeContent()
+	for _, name := range []string{"a", "b", "c"} {
+		backend.Write("video", name, []byte("0123456789"))
+	}
+	memBytes, diskBytes := cacheMemBytes.Value(), cacheDiskBytes.Value()
+	c := newTestCache(t, backend, 10, WithDiskCache(dir, 25))
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Errorf("cache file %s of an earlier run was kept", leftover)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("unrelated file %s was removed: %v", other, err)
+	}
+
+	// Memory holds one file and disk two, so a is evicted from both.
+	for _, name := range []string{"a", "b", "c"} {
+		readString(t, c, "video", name)
+	}
+	if got := cacheMemBytes.Value() - memBytes; got != 10 {
+		t.Errorf("memory tier bytes = %d, want 10", got)
+	}
+	if got := cacheDiskBytes.Value() - diskBytes; got != 20 {
+		t.Errorf("disk tier bytes = %d, want 20", got)
+	}
+	if got := len(cacheFiles(t, dir)); got != 2 {
+		t.Errorf("disk tier holds %d files, want 2", got)
+	}
+
+	diskHits := cacheDiskHits.Value()
+	readString(t, c, "video", "b")
+	if got := cacheDiskHits.Value() - diskHits; got != 1 {
+		t.Errorf("disk hits = %d, want 1", got)
+	}
+	if got := backend.readsOf("video/b"); got != 1 {
+		t.Errorf("backend reads of b = %d, want it served from disk", got)
+	}
+
+	if err := c.Delete("video"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if got := cacheFiles(t, dir); len(got) != 0 {
+		t.Errorf("disk tier files after delete = %v, want none", got)
+	}
+	if got := cacheMemBytes.Value() - memBytes; got != 0 {
+		t.Errorf("memory tier bytes after delete = %d, want 0", got)
+	}
+	if got := cacheDiskBytes.Value() - diskBytes; got != 0 {
+		t.Errorf("disk tier bytes after delete = %d, want 0", got)
+	}
+}
+
+func TestCacheExpiresAfterTTL(t *testing.T) {
+	backend := newFakeContent()
+	backend.Write("video", "manifest.mpd", []byte("old"))
+	dir := t.TempDir()
+	c := newTestCache(t, backend, 1<<20, WithCacheTTL(50*time.Millisecond), WithDiskCache(dir, 1<<20))
+	readString(t, c, "video", "manifest.mpd")
+
+	// Another frontend rewrites the file behind this cache's back.
+	backend.Write("video", "manifest.mpd", []byte("new"))
+	if got := readString(t, c, "video", "manifest.mpd"); got != "old" {
+		t.Errorf("Read within the TTL = %q, want the cached %q", got, "old")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if got := readString(t, c, "video", "manifest.mpd"); got != "new" {
+		t.Errorf("Read after the TTL = %q, want %q", got, "new")
+	}
+	if got := len(cacheFiles(t, dir)); got != 1 {
+		t.Errorf("disk tier holds %d files, want the expired copy replaced", got)
+	}
+}
@@ -0,0 +1,350 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// WithSharedCluster keeps membership and rebalances in etcd under prefix so
+// that every web frontend using the same prefix shares one ring. Frontends
+// elect a leader that runs all rebalances, and a new leader resumes the one
+// its predecessor was running; the others forward membership changes to it at
+// the admin address it advertises, which defaults to the admin listen address.
+func WithSharedCluster(endpoints []string, prefix, advertiseAdmin string) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.clusterEndpoints = endpoints
+		s.clusterPrefix = prefix
+		s.advertiseAdmin = advertiseAdmin
+	}
+}
+
+// etcdMembershipStore keeps membership as a single JSON document in etcd. The
+// etcd version of the key is the membership version, so Save can compare and
+// swap on it.
+type etcdMembershipStore struct {
+	cli *clientv3.Client
+	key string
+}
+
+var _ membershipStore = (*etcdMembershipStore)(nil)
+
+func newEtcdMembershipStore(cli *clientv3.Client, prefix string) *etcdMembershipStore {
+	return &etcdMembershipStore{cli: cli, key: prefix + "membership"}
+}
+
+// settledKey holds the membership version whose rebalance has finished, after
+// which no frontend needs the ring before it.
+func (st *etcdMembershipStore) settledKey() string {
+	return st.key + "/settled"
+}
+
+// Settle records that files have been moved for membership version.
+func (st *etcdMembershipStore) Settle(version int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := st.cli.Put(ctx, st.settledKey(), strconv.FormatInt(version, 10)); err != nil {
+		return fmt.Errorf("failed to save settled membership version: %w", err)
+	}
+	return nil
+}
+
+// settled returns the last version passed to Settle, or 0.
+func (st *etcdMembershipStore) settled() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := st.cli.Get(ctx, st.settledKey())
+	if err != nil || len(resp.Kvs) == 0 {
+		return 0, err
+	}
+	return strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
+}
+
+func (st *etcdMembershipStore) Load() (*clusterMembership, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := st.cli.Get(ctx, st.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	return decodeMembership(resp.Kvs[0].Value, resp.Kvs[0].Version)
+}
+
+func (st *etcdMembershipStore) Save(m *clusterMembership) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode membership: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := st.cli.Txn(ctx).
+		If(clientv3.Compare(clientv3.Version(st.key), "=", m.Version)).
+		Then(clientv3.OpPut(st.key, string(data))).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("failed to save membership: %w", err)
+	}
+	if !resp.Succeeded {
+		return ErrMembershipConflict
+	}
+	m.Version++
+	return nil
+}
+
+func decodeMembership(data []byte, version int64) (*clusterMembership, error) {
+	m := &clusterMembership{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to decode membership: %w", err)
+	}
+	m.Version = version
+	if m.Nodes == nil {
+		m.Nodes = make(map[string]string)
+	}
+	return m, nil
+}
+
+// watchMembership applies membership changes saved by other frontends, and
+// the leader's word that a rebalance has finished, until ctx is cancelled.
+func (s *NetworkVideoContentService) watchMembership(ctx context.Context, st *etcdMembershipStore) {
+	for ctx.Err() == nil {
+		wch := st.cli.Watch(ctx, st.key, clientv3.WithPrefix())
+		for wr := range wch {
+			if err := wr.Err(); err != nil {
+				log.Printf("DEBUG: Membership watch error: %v", err)
+				break
+			}
+			for _, ev := range wr.Events {
+				if ev.Type != clientv3.EventTypePut {
+					continue
+				}
+				switch string(ev.Kv.Key) {
+				case st.key:
+					m, err := decodeMembership(ev.Kv.Value, ev.Kv.Version)
+					if err != nil {
+						log.Printf("DEBUG: %v", err)
+						continue
+					}
+					s.applyRemoteMembership(m)
+				case st.settledKey():
+					version, err := strconv.ParseInt(string(ev.Kv.Value), 10, 64)
+					if err != nil {
+						log.Printf("DEBUG: Invalid settled membership version %q", ev.Kv.Value)
+						continue
+					}
+					s.settleMembership(version)
+				}
+			}
+		}
+		// The watch was cancelled or compacted; catch up before watching again.
+		if m, err := st.Load(); err == nil && m != nil {
+			s.applyRemoteMembership(m)
+		}
+		if version, err := st.settled(); err == nil {
+			s.settleMembership(version)
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// applyRemoteMembership switches to a newer membership in one step. The ring it
+// replaces is kept for fallback reads until the leader has moved the files.
+func (s *NetworkVideoContentService) applyRemoteMembership(m *clusterMembership) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m.Version <= s.membershipVersion {
+		return
+	}
+	log.Printf("DEBUG: Membership changed to version %d: %v", m.Version, m.Nodes)
+	prev := s.ring
+	if err := s.syncMembership(m); err != nil {
+		log.Printf("DEBUG: Failed to apply membership version %d: %v", m.Version, err)
+		return
+	}
+	if !slices.Equal(prev, s.ring) {
+		s.prevRing = prev
+	}
+	if m.Version <= s.settledVersion {
+		s.prevRing = nil
+	}
+}
+
+// settleMembership drops the previous ring once the rebalance for membership
+// version, or a later one, has finished.
+func (s *NetworkVideoContentService) settleMembership(version int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if version <= s.settledVersion {
+		return
+	}
+	s.settledVersion = version
+	if s.membershipVersion <= version && s.prevRing != nil {
+		log.Printf("DEBUG: Rebalance for membership version %d finished; dropping the previous ring", version)
+		s.prevRing = nil
+	}
+}
+
+// etcdRebalanceStore keeps rebalances in etcd so that whichever frontend leads
+// next can resume one. Each operation and each unfinished task is a JSON
+// document under its own key; tasks are deleted once done.
+type etcdRebalanceStore struct {
+	cli    *clientv3.Client
+	prefix string
+}
+
+var _ rebalanceStore = (*etcdRebalanceStore)(nil)
+
+// etcdTxnOps stays below etcd's default limit on operations per transaction.
+const etcdTxnOps = 100
+
+func newEtcdRebalanceStore(cli *clientv3.Client, prefix string) *etcdRebalanceStore {
+	return &etcdRebalanceStore{cli: cli, prefix: prefix + "rebalance/"}
+}
+
+func (st *etcdRebalanceStore) opKey(id string) string {
+	return st.prefix + "ops/" + id
+}
+
+func (st *etcdRebalanceStore) taskPrefix(opID string) string {
+	return st.prefix + "tasks/" + opID + "/"
+}
+
+func (st *etcdRebalanceStore) taskKey(opID string, t rebalanceTask) string {
+	return st.taskPrefix(opID) + t.Source + "/" + t.Path
+}
+
+func (st *etcdRebalanceStore) putOp(op rebalanceStatus) error {
+	data, err := json.Marshal(op)
+	if err != nil {
+		return fmt.Errorf("failed to encode rebalance: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = st.cli.Put(ctx, st.opKey(op.ID), string(data))
+	return err
+}
+
+func (st *etcdRebalanceStore) createOp(op rebalanceStatus) error {
+	if err := st.putOp(op); err != nil {
+		return fmt.Errorf("failed to insert rebalance: %w", err)
+	}
+	return nil
+}
+
+func (st *etcdRebalanceStore) updateOp(op rebalanceStatus) error {
+	if err := st.putOp(op); err != nil {
+		return fmt.Errorf("failed to update rebalance: %w", err)
+	}
+	return nil
+}
+
+func (st *etcdRebalanceStore) getOp(id string) (*rebalanceStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := st.cli.Get(ctx, st.opKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rebalance: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	var op rebalanceStatus
+	if err := json.Unmarshal(resp.Kvs[0].Value, &op); err != nil {
+		return nil, fmt.Errorf("failed to decode rebalance %s: %w", id, err)
+	}
+	return &op, nil
+}
+
+func (st *etcdRebalanceStore) listOps() ([]rebalanceStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := st.cli.Get(ctx, st.prefix+"ops/", clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rebalances: %w", err)
+	}
+	results := make([]rebalanceStatus, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var op rebalanceStatus
+		if err := json.Unmarshal(kv.Value, &op); err != nil {
+			return nil, fmt.Errorf("failed to decode rebalance %s: %w", kv.Key, err)
+		}
+		results = append(results, op)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].CreatedAt.After(results[j].CreatedAt) })
+	return results, nil
+}
+
+func (st *etcdRebalanceStore) addTasks(opID string, tasks []rebalanceTask) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for len(tasks) > 0 {
+		batch := tasks[:min(len(tasks), etcdTxnOps)]
+		tasks = tasks[len(batch):]
+		ops := make([]clientv3.Op, len(batch))
+		for i, t := range batch {
+			data, err := json.Marshal(t)
+			if err != nil {
+				return fmt.Errorf("failed to encode rebalance task: %w", err)
+			}
+			ops[i] = clientv3.OpPut(st.taskKey(opID, t), string(data))
+		}
+		if _, err := st.cli.Txn(ctx).Then(ops...).Commit(); err != nil {
+			return fmt.Errorf("failed to insert rebalance tasks: %w", err)
+		}
+	}
+	return nil
+}
+
+func (st *etcdRebalanceStore) unfinishedTasks(opID string) ([]rebalanceTask, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	resp, err := st.cli.Get(ctx, st.taskPrefix(opID), clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rebalance tasks: %w", err)
+	}
+	results := make([]rebalanceTask, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var t rebalanceTask
+		if err := json.Unmarshal(kv.Value, &t); err != nil {
+			return nil, fmt.Errorf("failed to decode rebalance task %s: %w", kv.Key, err)
+		}
+		if t.State != taskDone {
+			results = append(results, t)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	return results, nil
+}
+
+func (st *etcdRebalanceStore) setTaskState(opID string, t rebalanceTask, target, state, msg string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	key := st.taskKey(opID, t)
+	if state == taskDone {
+		if _, err := st.cli.Delete(ctx, key); err != nil {
+			return fmt.Errorf("failed to update rebalance task: %w", err)
+		}
+		return nil
+	}
+	t.Target, t.State, t.Error = target, state, msg
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to encode rebalance task: %w", err)
+	}
+	if _, err := st.cli.Put(ctx, key, string(data)); err != nil {
+		return fmt.Errorf("failed to update rebalance task: %w", err)
+	}
+	return nil
+}
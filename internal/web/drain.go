@@ -0,0 +1,247 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Node states reported by ListNodes. Draining and drained nodes take no new
+// writes but keep serving reads until they are decommissioned.
+const (
+	nodeActive   = "active"
+	nodeDraining = "draining"
+	nodeDrained  = "drained"
+)
+
+// DrainNode takes a node out of the write ring and starts copying its files to
+// their new owners. The node stays readable and is left drained once every copy
+// has been checked.
+func (s *NetworkVideoContentService) DrainNode(ctx context.Context, req *proto.DrainNodeRequest) (*proto.DrainNodeResponse, error) {
+	addr := req.GetNodeAddress()
+	log.Printf("DEBUG: DrainNode called for %s", addr)
+	if leader, err := s.leaderAdmin(ctx); err != nil {
+		return nil, err
+	} else if leader != nil {
+		return leader.DrainNode(ctx, req)
+	}
+
+	op, err := s.startRebalance(rebalanceDrain, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.DrainNodeResponse{OperationId: op.id}, nil
+}
+
+// UndrainNode undoes a drain: the node rejoins the ring and the files it owns
+// again are moved back to it. It is the way out for a drain whose copies failed
+// verification.
+func (s *NetworkVideoContentService) UndrainNode(ctx context.Context, req *proto.UndrainNodeRequest) (*proto.UndrainNodeResponse, error) {
+	addr := req.GetNodeAddress()
+	log.Printf("DEBUG: UndrainNode called for %s", addr)
+	if leader, err := s.leaderAdmin(ctx); err != nil {
+		return nil, err
+	} else if leader != nil {
+		return leader.UndrainNode(ctx, req)
+	}
+
+	s.mu.RLock()
+	_, draining := s.draining[addr]
+	s.mu.RUnlock()
+	if !draining {
+		return nil, status.Errorf(codes.FailedPrecondition, "node %s is not draining or drained", addr)
+	}
+	op, err := s.startRebalance(rebalanceAdd, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.UndrainNodeResponse{OperationId: op.id}, nil
+}
+
+// DecommissionNode removes a drained node from the cluster after checking again
+// that all of its files are on their owners. The node's own data is left as is.
+func (s *NetworkVideoContentService) DecommissionNode(ctx context.Context, req *proto.DecommissionNodeRequest) (*proto.DecommissionNodeResponse, error) {
+	addr := req.GetNodeAddress()
+	log.Printf("DEBUG: DecommissionNode called for %s", addr)
+	if leader, err := s.leaderAdmin(ctx); err != nil {
+		return nil, err
+	} else if leader != nil {
+		return leader.DecommissionNode(ctx, req)
+	}
+
+	// Holding opMu keeps a new rebalance from changing the ring under the check.
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+	if s.activeOp != nil && s.activeOp.node == addr {
+		return nil, status.Errorf(codes.FailedPrecondition, "node %s is still draining (rebalance %s)", addr, s.activeOp.id)
+	}
+	s.mu.RLock()
+	state, ok := s.draining[addr]
+	_, member := s.clients[addr]
+	s.mu.RUnlock()
+	switch {
+	case member:
+		return nil, status.Errorf(codes.FailedPrecondition, "node %s is active; drain it first", addr)
+	case !ok:
+		return nil, status.Errorf(codes.NotFound, "node %s not found", addr)
+	case state != nodeDrained:
+		return nil, status.Errorf(codes.FailedPrecondition, "node %s is %s, not drained", addr, state)
+	}
+
+	files, err := s.verifyDrain(ctx, addr)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "node %s failed verification: %v", addr, err)
+	}
+	if err := s.decommission(addr); err != nil {
+		if errors.Is(err, ErrMembershipConflict) {
+			return nil, status.Error(codes.Aborted, err.Error())
+		}
+		return nil, err
+	}
+	return &proto.DecommissionNodeResponse{VerifiedFiles: int64(len(files))}, nil
+}
+
+// completeDrain runs once a drain has copied every file. It checks the copies
+// and either marks the node drained or, for a remove, deletes the originals and
+// decommissions the node.
+func (s *NetworkVideoContentService) completeDrain(ctx context.Context, st rebalanceStatus) error {
+	files, err := s.verifyDrain(ctx, st.Node)
+	if err != nil {
+		return fmt.Errorf("verification failed: %w", err)
+	}
+	if st.Kind == rebalanceDrain {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if err := s.commitMembership(func(nodes map[string]string) { nodes[st.Node] = nodeDrained }); err != nil {
+			return err
+		}
+		s.draining[st.Node] = nodeDrained
+		log.Printf("DEBUG: Node %s drained, %d files verified", st.Node, len(files))
+		return nil
+	}
+
+	client, err := s.clientFor(st.Node)
+	if err != nil {
+		return err
+	}
+	for _, p := range files {
+		vid, fname, _ := splitContentPath(p)
+		if _, err := client.DeleteFile(ctx, &proto.DeleteFileRequest{VideoId: vid, Filename: fname}); err != nil {
+			log.Printf("DEBUG: Failed to delete %s from %s after drain: %v", p, st.Node, err)
+		}
+	}
+	return s.decommission(st.Node)
+}
+
+// verifyDrain checks that every file on node exists on its owner under the
+// current ring with the same size and SHA-256, and returns the files it
+// checked.
+func (s *NetworkVideoContentService) verifyDrain(ctx context.Context, node string) ([]string, error) {
+	type ownedVideo struct{ owner, videoId string }
+	owners := make(map[ownedVideo]map[string]*proto.FileInfo)
+	var files []string
+	missing := 0
+	err := s.forEachFile(ctx, node, "", func(f *proto.FileInfo) error {
+		vid, fname, ok := splitContentPath(f.Path)
+		if !ok {
+			return nil
+		}
+		owner := s.ownerOf(vid, fname)
+		if owner == "" {
+			return fmt.Errorf("no storage nodes available")
+		}
+		key := ownedVideo{owner, vid}
+		copies, ok := owners[key]
+		if !ok {
+			var err error
+			copies, err = s.listFileInfo(ctx, owner, vid+"/")
+			if err != nil {
+				return err
+			}
+			owners[key] = copies
+		}
+		if c, ok := copies[f.Path]; !ok || !s.sameContents(ctx, node, f, owner, c) {
+			log.Printf("DEBUG: Verify: %s from %s is missing or different on %s", f.Path, node, owner)
+			missing++
+		}
+		files = append(files, f.Path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if missing > 0 {
+		return nil, fmt.Errorf("%d of %d files are not on their new owners", missing, len(files))
+	}
+	return files, nil
+}
+
+// listFileInfo returns what addr lists for every file under prefix.
+func (s *NetworkVideoContentService) listFileInfo(ctx context.Context, addr, prefix string) (map[string]*proto.FileInfo, error) {
+	files := make(map[string]*proto.FileInfo)
+	err := s.forEachFile(ctx, addr, prefix, func(f *proto.FileInfo) error {
+		files[f.Path] = f
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// sameContents reports whether the listed file a on node aAddr has the same
+// size and checksum as b on bAddr. Nodes that do not list checksums are asked
+// for them with StatFile.
+func (s *NetworkVideoContentService) sameContents(ctx context.Context, aAddr string, a *proto.FileInfo, bAddr string, b *proto.FileInfo) bool {
+	if a.Size != b.Size {
+		return false
+	}
+	aSum, bSum := a.Sha256, b.Sha256
+	if aSum == "" {
+		aSum = s.statChecksum(ctx, aAddr, a.Path)
+	}
+	if bSum == "" {
+		bSum = s.statChecksum(ctx, bAddr, b.Path)
+	}
+	return aSum != "" && aSum == bSum
+}
+
+// statChecksum returns the checksum addr has recorded for path, or "" if it
+// cannot say.
+func (s *NetworkVideoContentService) statChecksum(ctx context.Context, addr, path string) string {
+	client, err := s.clientFor(addr)
+	if err != nil {
+		return ""
+	}
+	vid, fname, _ := splitContentPath(path)
+	st, err := client.StatFile(ctx, &proto.StatFileRequest{VideoId: vid, Filename: fname})
+	if err != nil {
+		log.Printf("DEBUG: Failed to stat %s on %s: %v", path, addr, err)
+		return ""
+	}
+	return st.GetSha256()
+}
+
+// decommission forgets a drained node and closes its connection. A node that
+// registered itself is not added back while its registration lasts.
+func (s *NetworkVideoContentService) decommission(addr string) error {
+	s.mu.Lock()
+	if err := s.commitMembership(func(nodes map[string]string) { delete(nodes, addr) }); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	delete(s.draining, addr)
+	s.disconnectNode(addr)
+	s.mu.Unlock()
+	if s.registry != nil {
+		s.registry.forget(addr)
+	}
+	log.Printf("DEBUG: Decommissioned node %s", addr)
+	return nil
+}
@@ -0,0 +1,573 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tritontube/internal/proto"
+
+	"github.com/klauspost/reedsolomon"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WithErasureCoding stores every file as dataShards data shards and
+// parityShards parity shards on distinct nodes instead of as a single copy.
+// A file stays readable with up to parityShards of its shards lost, and lost
+// shards are rebuilt in the background. The cluster needs at least
+// dataShards+parityShards nodes.
+func WithErasureCoding(dataShards, parityShards int) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.ecDataShards = dataShards
+		s.ecParityShards = parityShards
+	}
+}
+
+// DefaultShardScanInterval is how often the cluster leader looks for files
+// with lost shards, which reads alone only find for files that are read.
+const DefaultShardScanInterval = time.Hour
+
+// WithShardScanInterval sets how often the cluster leader lists every node's
+// shards and queues the files missing any for repair. Zero disables the scan;
+// reads and admin fsck still find lost shards.
+func WithShardScanInterval(d time.Duration) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.ecScanInterval = d
+	}
+}
+
+// erasureShards returns the data and parity shard counts files are stored
+// with, or zeros in single-copy mode.
+func (s *NetworkVideoContentService) erasureShards() (dataShards, parityShards int) {
+	if s.erasure == nil {
+		return 0, 0
+	}
+	return s.erasure.dataShards, s.erasure.parityShards
+}
+
+// storageMode describes a storage mode for error messages.
+func storageMode(dataShards, parityShards int) string {
+	if dataShards == 0 {
+		return "as single copies"
+	}
+	return fmt.Sprintf("erasure coded with %d data and %d parity shards", dataShards, parityShards)
+}
+
+// Every shard starts with a header recording how its file was encoded, so a
+// shard can be checked against its siblings without any other state.
+const (
+	shardMagic      = "TTEC"
+	shardVersion    = 1
+	shardHeaderSize = 4 + 4 + 8 + sha256.Size // magic, version/k/m/index, size, checksum
+)
+
+// shardRepairQueueSize bounds the files waiting for lost shards to be rebuilt.
+// Reads that find more missing shards drop their repair and rely on fsck.
+const shardRepairQueueSize = 1024
+
+type shardHeader struct {
+	dataShards, parityShards, index int
+	size                            uint64
+	sum                             [sha256.Size]byte
+}
+
+// erasure encodes files into shards and rebuilds them.
+type erasure struct {
+	dataShards, parityShards int
+	enc                      reedsolomon.Encoder
+
+	mu      sync.Mutex
+	queued  map[string]bool // videoId/filename waiting for repair
+	repairs chan string
+}
+
+func newErasure(dataShards, parityShards int) (*erasure, error) {
+	if dataShards < 1 || parityShards < 0 || dataShards+parityShards > 255 {
+		return nil, fmt.Errorf("invalid erasure coding %d+%d", dataShards, parityShards)
+	}
+	enc, err := reedsolomon.New(dataShards, parityShards)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create erasure encoder: %w", err)
+	}
+	return &erasure{
+		dataShards:   dataShards,
+		parityShards: parityShards,
+		enc:          enc,
+		queued:       make(map[string]bool),
+		repairs:      make(chan string, shardRepairQueueSize),
+	}, nil
+}
+
+func (e *erasure) shards() int {
+	return e.dataShards + e.parityShards
+}
+
+// shardName is the file name shard i of filename is stored under.
+func shardName(filename string, i int) string {
+	return filename + ".ec" + strconv.Itoa(i)
+}
+
+// parseShardName splits a shard's file name into its file's name and index.
+func parseShardName(name string) (string, int, bool) {
+	dot := strings.LastIndex(name, ".ec")
+	if dot <= 0 {
+		return "", 0, false
+	}
+	i, err := strconv.Atoi(name[dot+3:])
+	if err != nil || i < 0 || strconv.Itoa(i) != name[dot+3:] {
+		return "", 0, false
+	}
+	return name[:dot], i, true
+}
+
+// encode splits data into shards, each with its header.
+func (e *erasure) encode(data []byte) ([][]byte, error) {
+	hdr := shardHeader{
+		dataShards:   e.dataShards,
+		parityShards: e.parityShards,
+		size:         uint64(len(data)),
+		sum:          sha256.Sum256(data),
+	}
+	if len(data) == 0 {
+		// Split needs at least one byte; the header's size trims it off again.
+		data = []byte{0}
+	}
+	payloads, err := e.enc.Split(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.enc.Encode(payloads); err != nil {
+		return nil, err
+	}
+	shards := make([][]byte, len(payloads))
+	for i, p := range payloads {
+		hdr.index = i
+		shards[i] = append(hdr.marshal(), p...)
+	}
+	return shards, nil
+}
+
+func (h shardHeader) marshal() []byte {
+	b := make([]byte, shardHeaderSize)
+	copy(b, shardMagic)
+	b[4] = shardVersion
+	b[5] = byte(h.dataShards)
+	b[6] = byte(h.parityShards)
+	b[7] = byte(h.index)
+	binary.BigEndian.PutUint64(b[8:], h.size)
+	copy(b[16:], h.sum[:])
+	return b
+}
+
+func parseShard(b []byte) (shardHeader, []byte, error) {
+	var h shardHeader
+	if len(b) < shardHeaderSize || string(b[:4]) != shardMagic {
+		return h, nil, errors.New("not an erasure coded shard")
+	}
+	if b[4] != shardVersion {
+		return h, nil, fmt.Errorf("unsupported shard version %d", b[4])
+	}
+	h.dataShards, h.parityShards, h.index = int(b[5]), int(b[6]), int(b[7])
+	h.size = binary.BigEndian.Uint64(b[8:])
+	copy(h.sum[:], b[16:shardHeaderSize])
+	return h, b[shardHeaderSize:], nil
+}
+
+// assemble matches the shards that were read, nil where missing, into the
+// payloads of a single version of the file. Shards that are unreadable, from
+// another encoding or from an older write of the file count as missing.
+func (e *erasure) assemble(raw [][]byte) (shardHeader, [][]byte, []int, error) {
+	type version struct {
+		size uint64
+		sum  [sha256.Size]byte
+	}
+	headers := make([]*shardHeader, len(raw))
+	counts := make(map[version]int)
+	for i, b := range raw {
+		if b == nil {
+			continue
+		}
+		h, _, err := parseShard(b)
+		if err != nil || h.index != i || h.dataShards != e.dataShards || h.parityShards != e.parityShards {
+			continue
+		}
+		headers[i] = &h
+		counts[version{h.size, h.sum}]++
+	}
+	var best version
+	for v, n := range counts {
+		if n > counts[best] {
+			best = v
+		}
+	}
+	if counts[best] < e.dataShards {
+		return shardHeader{}, nil, nil, status.Errorf(codes.Unavailable, "only %d of the %d shards needed are available", counts[best], e.dataShards)
+	}
+
+	var hdr shardHeader
+	payloads := make([][]byte, len(raw))
+	var missing []int
+	for i, h := range headers {
+		if h == nil || h.size != best.size || h.sum != best.sum {
+			missing = append(missing, i)
+			continue
+		}
+		hdr = *h
+		payloads[i] = raw[i][shardHeaderSize:]
+	}
+	return hdr, payloads, missing, nil
+}
+
+// decode rebuilds a file from its shards and checks it against the checksum
+// recorded when it was written.
+func (e *erasure) decode(raw [][]byte) ([]byte, []int, error) {
+	hdr, payloads, missing, err := e.assemble(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := e.enc.ReconstructData(payloads); err != nil {
+		return nil, nil, fmt.Errorf("failed to reconstruct: %w", err)
+	}
+	var buf bytes.Buffer
+	if hdr.size > 0 {
+		if err := e.enc.Join(&buf, payloads, int(hdr.size)); err != nil {
+			return nil, nil, fmt.Errorf("failed to join shards: %w", err)
+		}
+	}
+	data := buf.Bytes()
+	if sha256.Sum256(data) != hdr.sum {
+		return nil, nil, status.Error(codes.DataLoss, "reconstructed file does not match its checksum")
+	}
+	return data, missing, nil
+}
+
+// shardOwners returns the node for each shard of videoId/filename under the
+// current and the previous ring.
+func (s *NetworkVideoContentService) shardOwners(videoId, filename string) (owners, prev []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key := s.placement.keyFor(videoId, filename)
+	return s.ring.lookupN(key, s.erasure.shards()), s.prevRing.lookupN(key, s.erasure.shards())
+}
+
+// writeErasure encodes a file and writes each shard to its owner.
+func (s *NetworkVideoContentService) writeErasure(ctx context.Context, videoId, filename string, data []byte) error {
+	owners, _ := s.shardOwners(videoId, filename)
+	if len(owners) < s.erasure.shards() {
+		return fmt.Errorf("erasure coding %d+%d needs %d storage nodes, have %d",
+			s.erasure.dataShards, s.erasure.parityShards, s.erasure.shards(), len(owners))
+	}
+	shards, err := s.erasure.encode(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s/%s: %w", videoId, filename, err)
+	}
+	errs := make([]error, len(shards))
+	var wg sync.WaitGroup
+	for i := range shards {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.writeShard(ctx, owners[i], videoId, shardName(filename, i), shards[i])
+		}(i)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (s *NetworkVideoContentService) writeShard(ctx context.Context, addr, videoId, name string, shard []byte) error {
+	client, err := s.clientFor(addr)
+	if err != nil {
+		return err
+	}
+	return s.withRetry(ctx, "write "+videoId+"/"+name+" to "+addr, func(ctx context.Context) error {
+		_, err := client.WriteFile(ctx, &proto.WriteFileRequest{VideoId: videoId, Filename: name, Data: shard})
+		return err
+	})
+}
+
+// readShards reads shards first to last-1 of a file into raw, leaving nil the
+// shards that cannot be read. A shard missing from its owner is looked for on
+// its previous owner, where it may still be while a rebalance moves it.
+func (s *NetworkVideoContentService) readShards(ctx context.Context, videoId, filename string, raw [][]byte, first, last int) {
+	owners, prev := s.shardOwners(videoId, filename)
+	var wg sync.WaitGroup
+	for i := first; i < last && i < len(owners); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := shardName(filename, i)
+			addrs := []string{owners[i]}
+			if i < len(prev) && prev[i] != owners[i] {
+				addrs = append(addrs, prev[i])
+			}
+			for _, addr := range addrs {
+				client, err := s.clientFor(addr)
+				if err != nil {
+					continue
+				}
+				data, err := s.readFile(ctx, addr, client, videoId, name)
+				if err == nil {
+					raw[i] = data
+					return
+				}
+				log.Printf("DEBUG: Failed to read shard %s/%s from %s: %v", videoId, name, addr, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// readErasure reads a file's data shards and joins them. Only if one of them
+// cannot be used are the parity shards read to rebuild the file, and a repair
+// is queued for the lost shards.
+func (s *NetworkVideoContentService) readErasure(ctx context.Context, videoId, filename string) ([]byte, error) {
+	e := s.erasure
+	raw := make([][]byte, e.shards())
+	s.readShards(ctx, videoId, filename, raw, 0, e.dataShards)
+	if data, _, err := e.decode(raw); err == nil {
+		// Every data shard was intact; the parity shards were not read.
+		return data, nil
+	}
+	s.readShards(ctx, videoId, filename, raw, e.dataShards, e.shards())
+	data, missing, err := e.decode(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s/%s: %w", videoId, filename, err)
+	}
+	if len(missing) > 0 {
+		log.Printf("DEBUG: Read %s/%s with shards %v missing", videoId, filename, missing)
+		s.queueShardRepair(videoId, filename)
+	}
+	return data, nil
+}
+
+// queueShardRepair asks the background repairer to rebuild a file's lost
+// shards, unless it is already waiting.
+func (s *NetworkVideoContentService) queueShardRepair(videoId, filename string) {
+	e := s.erasure
+	key := videoId + "/" + filename
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.queued[key] {
+		return
+	}
+	select {
+	case e.repairs <- key:
+		e.queued[key] = true
+	default:
+		log.Printf("DEBUG: Shard repair queue full, dropping %s", key)
+	}
+}
+
+// runShardRepairs rebuilds the lost shards of queued files until ctx is
+// cancelled.
+func (s *NetworkVideoContentService) runShardRepairs(ctx context.Context) {
+	e := s.erasure
+	for {
+		var key string
+		select {
+		case key = <-e.repairs:
+		case <-ctx.Done():
+			return
+		}
+		e.mu.Lock()
+		delete(e.queued, key)
+		e.mu.Unlock()
+
+		vid, fname, _ := splitContentPath(key)
+		repaired, err := s.repairShards(ctx, vid, fname)
+		if err != nil {
+			log.Printf("DEBUG: Failed to repair shards of %s: %v", key, err)
+			continue
+		}
+		if len(repaired) > 0 {
+			log.Printf("DEBUG: Rebuilt shards %v of %s", repaired, key)
+		}
+	}
+}
+
+// runShardScans periodically queues the files with lost shards for repair
+// while this frontend leads the cluster, until ctx is cancelled.
+func (s *NetworkVideoContentService) runShardScans(ctx context.Context) {
+	ticker := time.NewTicker(s.ecScanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if !s.isLeader() {
+			continue
+		}
+		queued, err := s.scanShards(ctx)
+		if err != nil {
+			log.Printf("DEBUG: Shard scan failed: %v", err)
+			continue
+		}
+		log.Printf("DEBUG: Shard scan found %d files missing shards", queued)
+	}
+}
+
+// scanShards lists the shards on every node and queues a repair for each file
+// that is missing any of its shards on their owners, or previous owners while
+// a rebalance moves them. Shards owned by a node that cannot be listed are not
+// counted as missing, since a repair could not write them either. With a
+// metadata service, only the shards of published videos are checked, so
+// uploads in flight and deleted videos are left alone. It returns how many
+// files were missing shards; a file that does not fit in the repair queue is
+// left for the next scan.
+func (s *NetworkVideoContentService) scanShards(ctx context.Context) (int, error) {
+	var known map[string]bool
+	if s.metadata != nil {
+		videos, err := s.metadata.List()
+		if err != nil {
+			return 0, fmt.Errorf("failed to list videos: %w", err)
+		}
+		known = make(map[string]bool, len(videos))
+		for _, v := range videos {
+			known[v.Id] = true
+		}
+	}
+	s.mu.RLock()
+	addrs := s.members()
+	for addr := range s.draining {
+		addrs = append(addrs, addr)
+	}
+	s.mu.RUnlock()
+
+	// found records, per file, the shards held by the node that should have them.
+	found := make(map[string]map[int]bool)
+	unlisted := make(map[string]bool)
+	for _, addr := range addrs {
+		err := s.forEachFile(ctx, addr, "", func(f *proto.FileInfo) error {
+			vid, name, ok := splitContentPath(f.Path)
+			if !ok || (known != nil && !known[vid]) {
+				return nil
+			}
+			fname, i, ok := parseShardName(name)
+			if !ok {
+				return nil
+			}
+			owners, prev := s.shardOwners(vid, fname)
+			if (i >= len(owners) || owners[i] != addr) && (i >= len(prev) || prev[i] != addr) {
+				return nil
+			}
+			key := vid + "/" + fname
+			if found[key] == nil {
+				found[key] = make(map[int]bool)
+			}
+			found[key][i] = true
+			return nil
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, err
+			}
+			log.Printf("DEBUG: Shard scan failed to list files on %s: %v", addr, err)
+			unlisted[addr] = true
+		}
+	}
+
+	var keys []string
+	for key, shards := range found {
+		vid, fname, _ := splitContentPath(key)
+		owners, _ := s.shardOwners(vid, fname)
+		for i, owner := range owners {
+			if !shards[i] && !unlisted[owner] {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		vid, fname, _ := splitContentPath(key)
+		s.queueShardRepair(vid, fname)
+	}
+	return len(keys), nil
+}
+
+// repairShards re-encodes the shards of a file that are missing, corrupt or
+// stale and writes them to their owners. It returns the rebuilt indexes.
+func (s *NetworkVideoContentService) repairShards(ctx context.Context, videoId, filename string) ([]int, error) {
+	raw := make([][]byte, s.erasure.shards())
+	s.readShards(ctx, videoId, filename, raw, 0, len(raw))
+	hdr, payloads, missing, err := s.erasure.assemble(raw)
+	if err != nil || len(missing) == 0 {
+		return nil, err
+	}
+	if err := s.erasure.enc.Reconstruct(payloads); err != nil {
+		return nil, fmt.Errorf("failed to reconstruct: %w", err)
+	}
+	owners, _ := s.shardOwners(videoId, filename)
+	var repaired []int
+	var errs []error
+	for _, i := range missing {
+		if i >= len(owners) {
+			errs = append(errs, fmt.Errorf("no node for shard %d", i))
+			continue
+		}
+		hdr.index = i
+		shard := append(hdr.marshal(), payloads[i]...)
+		if err := s.writeShard(ctx, owners[i], videoId, shardName(filename, i), shard); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		repaired = append(repaired, i)
+	}
+	return repaired, errors.Join(errs...)
+}
+
+// checkShards checks every shard of a file on its owner for fsck. With repair
+// set it rebuilds the shards that are lost, as long as enough remain.
+func (s *NetworkVideoContentService) checkShards(ctx context.Context, vid, fname string, repair bool) *proto.SegmentProblem {
+	owners, prev := s.shardOwners(vid, fname)
+	if len(owners) < s.erasure.shards() {
+		return &proto.SegmentProblem{Filename: fname, Problem: "not enough storage nodes for erasure coding"}
+	}
+	var bad []string
+	var nodes []string
+	for i, owner := range owners {
+		name := shardName(fname, i)
+		if s.statVerified(ctx, owner, vid, name) == nil {
+			continue
+		}
+		if i < len(prev) && prev[i] != owner && s.statVerified(ctx, prev[i], vid, name) == nil {
+			continue
+		}
+		bad = append(bad, strconv.Itoa(i))
+		nodes = append(nodes, owner)
+	}
+	if len(bad) == 0 {
+		return nil
+	}
+	sort.Strings(nodes)
+	p := &proto.SegmentProblem{
+		Filename:    fname,
+		NodeAddress: strings.Join(nodes, ","),
+		Problem:     fmt.Sprintf("missing or corrupt shards %s", strings.Join(bad, ",")),
+	}
+	if len(bad) > s.erasure.parityShards {
+		p.Problem += "; too few shards left to rebuild"
+		return p
+	}
+	if repair {
+		if _, err := s.repairShards(ctx, vid, fname); err != nil {
+			p.Problem += fmt.Sprintf("; repair failed: %v", err)
+			return p
+		}
+		p.Repaired = true
+		p.RepairedFrom = "parity"
+	}
+	return p
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"context"
+	"testing"
+
+	"tritontube/internal/proto"
+)
+
+// newErasureService returns a 2+1 erasure coded service over three counting
+// nodes, and the node that owns each shard of videoId/filename.
+func newErasureService(t *testing.T, videoId, filename string, opts ...NetworkOption) (*NetworkVideoContentService, []*slowNode) {
+	t.Helper()
+	nodes := []*slowNode{startSlowNode(t), startSlowNode(t), startSlowNode(t)}
+	byAddr := make(map[string]*slowNode)
+	var addrs []string
+	for _, n := range nodes {
+		byAddr[n.addr] = n
+		addrs = append(addrs, n.addr)
+	}
+	opts = append([]NetworkOption{WithErasureCoding(2, 1)}, opts...)
+	s, err := NewNetworkVideoContentService(freeAddr(t), addrs, opts...)
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	owners, _ := s.shardOwners(videoId, filename)
+	var shardNodes []*slowNode
+	for _, addr := range owners {
+		shardNodes = append(shardNodes, byAddr[addr])
+	}
+	return s, shardNodes
+}
+
+func deleteShard(t *testing.T, s *NetworkVideoContentService, n *slowNode, videoId, name string) {
+	t.Helper()
+	client, err := s.clientFor(n.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DeleteFile(context.Background(), &proto.DeleteFileRequest{VideoId: videoId, Filename: name}); err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+}
+
+func TestErasureReadFetchesParityOnlyWhenNeeded(t *testing.T) {
+	s, shards := newErasureService(t, "video", "manifest.mpd")
+	if err := s.Write("video", "manifest.mpd", []byte("erasure coded manifest")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if _, err := s.Read("video", "manifest.mpd"); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got := shards[2].reads.Load(); got != 0 {
+		t.Errorf("parity shard read %d times with every data shard intact, want 0", got)
+	}
+
+	deleteShard(t, s, shards[0], "video", shardName("manifest.mpd", 0))
+	data, err := s.Read("video", "manifest.mpd")
+	if err != nil {
+		t.Fatalf("Read with a data shard lost: %v", err)
+	}
+	if string(data) != "erasure coded manifest" {
+		t.Errorf("Read = %q, want %q", data, "erasure coded manifest")
+	}
+	// The repair the read queues may read the parity shard again.
+	if shards[2].reads.Load() == 0 {
+		t.Error("parity shard not read with a data shard lost")
+	}
+}
+
+func TestShardScanRepairsUnreadFiles(t *testing.T) {
+	s, shards := newErasureService(t, "video", "manifest.mpd", WithShardScanInterval(0))
+	if err := s.Write("video", "manifest.mpd", []byte("erasure coded manifest")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	name := shardName("manifest.mpd", 2)
+	deleteShard(t, s, shards[2], "video", name)
+
+	queued, err := s.scanShards(context.Background())
+	if err != nil {
+		t.Fatalf("scanShards: %v", err)
+	}
+	if queued != 1 {
+		t.Errorf("scan found %d files missing shards, want 1", queued)
+	}
+	waitFor(t, "the lost parity shard to be rebuilt", func() bool {
+		return s.statVerified(context.Background(), shards[2].addr, "video", name) == nil
+	})
+
+	if queued, err := s.scanShards(context.Background()); err != nil || queued != 0 {
+		t.Errorf("scan after repair = %d, %v; want nothing missing", queued, err)
+	}
+}
@@ -5,8 +5,12 @@ package web
 import (
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
 	"path/filepath"
+
+	"tritontube/internal/atomicfile"
+	"tritontube/internal/pathcheck"
 )
 
 type FSVideoContentService struct {
@@ -20,22 +24,35 @@ func NewFSVideoContentService(baseDir string) (*FSVideoContentService, error) {
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create base directory: %w", err)
 	}
+	n, err := atomicfile.Sweep(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove interrupted writes: %w", err)
+	}
+	if n > 0 {
+		log.Printf("DEBUG: Removed %d files left by interrupted writes", n)
+	}
 	return &FSVideoContentService{baseDir: baseDir}, nil
 }
 
 func (s *FSVideoContentService) Write(videoId string, filename string, data []byte) error {
+	if err := pathcheck.Validate(videoId, filename); err != nil {
+		return err
+	}
 	videoDir := filepath.Join(s.baseDir, videoId)
 	if err := os.MkdirAll(videoDir, 0755); err != nil {
 		return fmt.Errorf("failed to create video directory: %w", err)
 	}
 	fullPath := filepath.Join(videoDir, filename)
-	if err := ioutil.WriteFile(fullPath, data, 0644); err != nil {
+	if err := atomicfile.WriteFile(fullPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 	return nil
 }
 
 func (s *FSVideoContentService) Read(videoId string, filename string) ([]byte, error) {
+	if err := pathcheck.Validate(videoId, filename); err != nil {
+		return nil, err
+	}
 	fullPath := filepath.Join(s.baseDir, videoId, filename)
 	data, err := ioutil.ReadFile(fullPath)
 	if err != nil {
@@ -43,3 +60,13 @@ func (s *FSVideoContentService) Read(videoId string, filename string) ([]byte, e
 	}
 	return data, nil
 }
+
+func (s *FSVideoContentService) Delete(videoId string) error {
+	if err := pathcheck.ValidateVideoID(videoId); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(s.baseDir, videoId)); err != nil {
+		return fmt.Errorf("failed to delete video directory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package web
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFSInterruptedWriteKeepsOldContents(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFSVideoContentService(dir)
+	if err != nil {
+		t.Fatalf("NewFSVideoContentService: %v", err)
+	}
+	if err := s.Write("video", "manifest.mpd", []byte("old manifest")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// Simulate a crash part way through rewriting the manifest: the new
+	// contents only reached the temporary file.
+	leftover := filepath.Join(dir, "video", ".tmp-manifest.mpd-123")
+	if err := os.WriteFile(leftover, []byte("new man"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = NewFSVideoContentService(dir)
+	if err != nil {
+		t.Fatalf("NewFSVideoContentService after crash: %v", err)
+	}
+	got, err := s.Read("video", "manifest.mpd")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if string(got) != "old manifest" {
+		t.Errorf("Read = %q, want %q", got, "old manifest")
+	}
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Errorf("interrupted write %s was not removed at startup", leftover)
+	}
+}
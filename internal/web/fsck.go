@@ -0,0 +1,213 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"sync"
+
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Problems CheckConsistency reports for a file on its owner.
+const (
+	problemMissing     = "missing"
+	problemCorrupt     = "corrupt"
+	problemUnreachable = "unreachable"
+)
+
+// CheckConsistency checks that every file each video's manifest references is
+// intact on its ring owner, sending one result per video. With repair set it
+// restores missing or corrupt files from an intact copy on another node.
+func (s *NetworkVideoContentService) CheckConsistency(req *proto.CheckConsistencyRequest, stream proto.VideoContentAdminService_CheckConsistencyServer) error {
+	ctx := stream.Context()
+	log.Printf("DEBUG: CheckConsistency called for %d videos (repair %v)", len(req.GetVideoIds()), req.GetRepair())
+	if req.GetRepair() {
+		if err := s.requireOperator(ctx, "CheckConsistency with repair"); err != nil {
+			return err
+		}
+	}
+	if leader, err := s.leaderAdmin(ctx); err != nil {
+		return err
+	} else if leader != nil {
+		return relayConsistency(leader, req, stream)
+	}
+
+	videoIds := req.GetVideoIds()
+	if len(videoIds) == 0 {
+		if s.metadata == nil {
+			return status.Error(codes.FailedPrecondition, "checking every video needs the metadata service")
+		}
+		videos, err := s.metadata.List()
+		if err != nil {
+			return fmt.Errorf("failed to list videos: %w", err)
+		}
+		for _, v := range videos {
+			videoIds = append(videoIds, v.Id)
+		}
+		sort.Strings(videoIds)
+	}
+	for _, vid := range videoIds {
+		if err := stream.Send(s.checkVideo(ctx, vid, req.GetRepair())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relayConsistency streams a leader's CheckConsistency to a client of this
+// frontend.
+func relayConsistency(leader proto.VideoContentAdminServiceClient, req *proto.CheckConsistencyRequest, stream proto.VideoContentAdminService_CheckConsistencyServer) error {
+	in, err := leader.CheckConsistency(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	for {
+		h, err := in.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(h); err != nil {
+			return err
+		}
+	}
+}
+
+// checkVideo checks a video's manifest and every segment it references.
+func (s *NetworkVideoContentService) checkVideo(ctx context.Context, vid string, repair bool) *proto.VideoHealth {
+	h := &proto.VideoHealth{VideoId: vid, Files: 1}
+	if p := s.checkFile(ctx, vid, manifestFile, repair); p != nil {
+		h.Problems = append(h.Problems, p)
+		if !p.Repaired {
+			h.Error = "manifest unavailable"
+			return h
+		}
+	}
+	data, err := s.Read(vid, manifestFile)
+	if err != nil {
+		h.Error = fmt.Sprintf("failed to read manifest: %v", err)
+		return h
+	}
+	segments, err := manifestSegments(data)
+	if err != nil {
+		h.Error = err.Error()
+		return h
+	}
+	h.Files += int32(len(segments))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.rebalanceWorkers)
+	for _, fname := range segments {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(fname string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if p := s.checkFile(ctx, vid, fname, repair); p != nil {
+				mu.Lock()
+				h.Problems = append(h.Problems, p)
+				mu.Unlock()
+			}
+		}(fname)
+	}
+	wg.Wait()
+	sort.Slice(h.Problems, func(i, j int) bool { return h.Problems[i].Filename < h.Problems[j].Filename })
+
+	h.Healthy = true
+	for _, p := range h.Problems {
+		if !p.Repaired {
+			h.Healthy = false
+		}
+	}
+	return h
+}
+
+// checkFile checks one file on its owner and returns what is wrong with it, or
+// nil if it is intact. A file still waiting to move off its previous owner
+// counts as intact while that copy is.
+func (s *NetworkVideoContentService) checkFile(ctx context.Context, vid, fname string, repair bool) *proto.SegmentProblem {
+	if s.erasure != nil {
+		return s.checkShards(ctx, vid, fname, repair)
+	}
+	owner := s.ownerOf(vid, fname)
+	if owner == "" {
+		return &proto.SegmentProblem{Filename: fname, Problem: "no storage nodes available"}
+	}
+	err := s.statVerified(ctx, owner, vid, fname)
+	if err == nil {
+		return nil
+	}
+	if prev := s.previousOwner(vid, fname); prev != "" && prev != owner {
+		if s.statVerified(ctx, prev, vid, fname) == nil {
+			return nil
+		}
+	}
+
+	p := &proto.SegmentProblem{Filename: fname, NodeAddress: owner}
+	switch status.Code(err) {
+	case codes.NotFound:
+		p.Problem = problemMissing
+	case codes.DataLoss:
+		p.Problem = problemCorrupt
+	default:
+		p.Problem = fmt.Sprintf("%s: %v", problemUnreachable, err)
+		return p
+	}
+	if repair {
+		s.repairFile(ctx, p, vid, fname, owner)
+	}
+	return p
+}
+
+// statVerified asks addr to re-read a file and check its checksum.
+func (s *NetworkVideoContentService) statVerified(ctx context.Context, addr, vid, fname string) error {
+	client, err := s.clientFor(addr)
+	if err != nil {
+		return err
+	}
+	_, err = client.StatFile(ctx, &proto.StatFileRequest{VideoId: vid, Filename: fname, Verify: true})
+	return err
+}
+
+// repairFile copies an intact copy of a file from any other node to owner.
+func (s *NetworkVideoContentService) repairFile(ctx context.Context, p *proto.SegmentProblem, vid, fname, owner string) {
+	s.mu.RLock()
+	candidates := s.members()
+	for addr := range s.draining {
+		candidates = append(candidates, addr)
+	}
+	s.mu.RUnlock()
+
+	dst, err := s.clientFor(owner)
+	if err != nil {
+		p.Problem += fmt.Sprintf("; repair failed: %v", err)
+		return
+	}
+	for _, addr := range candidates {
+		if addr == owner || s.statVerified(ctx, addr, vid, fname) != nil {
+			continue
+		}
+		src, err := s.clientFor(addr)
+		if err != nil {
+			continue
+		}
+		if _, err := s.copyFile(ctx, src, dst, addr, owner, vid, fname); err != nil {
+			log.Printf("DEBUG: Failed to repair %s/%s from %s: %v", vid, fname, addr, err)
+			continue
+		}
+		log.Printf("DEBUG: Repaired %s/%s on %s from %s", vid, fname, owner, addr)
+		p.Repaired = true
+		p.RepairedFrom = addr
+		return
+	}
+	p.Problem += "; no intact copy found"
+}
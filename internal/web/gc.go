@@ -0,0 +1,194 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultGCGrace is how old a file no video refers to must be before the
+// garbage collector deletes it, which leaves uploads in flight alone.
+const DefaultGCGrace = time.Hour
+
+// WithMetadataService gives the admin service the list of videos, which
+// garbage collection and consistency checks need.
+func WithMetadataService(metadata VideoMetadataService) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.metadata = metadata
+	}
+}
+
+// WithSharedMetadata declares that every frontend of a shared cluster uses the
+// same metadata store. Without it each frontend only knows the videos uploaded
+// through it, so the leader cannot tell orphans from other frontends' videos
+// and garbage collection only reports what it would delete.
+func WithSharedMetadata(shared bool) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.sharedMetadata = shared
+	}
+}
+
+// WithGarbageCollection sets how old a file no video in metadata refers to
+// must be before garbage collection deletes it. If interval is positive the
+// leader also collects garbage on that schedule.
+func WithGarbageCollection(grace, interval time.Duration) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.gcGrace = grace
+		s.gcInterval = interval
+	}
+}
+
+// CollectGarbage lists the files on every node whose video is unknown to the
+// metadata service and, unless this is a dry run, deletes them.
+func (s *NetworkVideoContentService) CollectGarbage(ctx context.Context, req *proto.CollectGarbageRequest) (*proto.CollectGarbageResponse, error) {
+	log.Printf("DEBUG: CollectGarbage called (dry run %v)", req.GetDryRun())
+	if !req.GetDryRun() {
+		if err := s.requireOperator(ctx, "CollectGarbage without dry run"); err != nil {
+			return nil, err
+		}
+	}
+	if leader, err := s.leaderAdmin(ctx); err != nil {
+		return nil, err
+	} else if leader != nil {
+		return leader.CollectGarbage(ctx, req)
+	}
+	if s.metadata == nil {
+		return nil, status.Error(codes.FailedPrecondition, "garbage collection needs the metadata service")
+	}
+	if !req.GetDryRun() && !s.mayDeleteGarbage() {
+		return nil, status.Error(codes.FailedPrecondition,
+			"metadata is not shared by every frontend of this cluster; only dry runs are allowed")
+	}
+	grace := s.gcGrace
+	if req.GetGraceSeconds() > 0 {
+		grace = time.Duration(req.GetGraceSeconds()) * time.Second
+	}
+	return s.collectGarbage(ctx, grace, req.GetDryRun())
+}
+
+func (s *NetworkVideoContentService) collectGarbage(ctx context.Context, grace time.Duration, dryRun bool) (*proto.CollectGarbageResponse, error) {
+	// Take the node list before the metadata, so a file whose upload finishes
+	// while the nodes are scanned is either known or within the grace period.
+	s.mu.RLock()
+	addrs := s.members()
+	for addr := range s.draining {
+		addrs = append(addrs, addr)
+	}
+	s.mu.RUnlock()
+
+	known, err := s.knownVideos()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-grace).Unix()
+
+	resp := &proto.CollectGarbageResponse{}
+	for _, addr := range addrs {
+		err := s.forEachFile(ctx, addr, "", func(f *proto.FileInfo) error {
+			resp.ScannedFiles++
+			vid, _, ok := splitContentPath(f.Path)
+			if !ok || known[vid] || f.ModTime > cutoff {
+				return nil
+			}
+			resp.Orphans = append(resp.Orphans, &proto.OrphanFile{
+				NodeAddress: addr,
+				Path:        f.Path,
+				Size:        f.Size,
+				ModTime:     f.ModTime,
+			})
+			return nil
+		})
+		if err != nil {
+			resp.Errors = append(resp.Errors, err.Error())
+		}
+	}
+	if dryRun || len(resp.Orphans) == 0 {
+		return resp, nil
+	}
+	if len(known) == 0 {
+		return nil, status.Error(codes.FailedPrecondition, "metadata lists no videos; refusing to delete every file")
+	}
+
+	for _, o := range resp.Orphans {
+		if err := s.deleteOrphan(ctx, o); err != nil {
+			resp.Errors = append(resp.Errors, err.Error())
+			continue
+		}
+		resp.DeletedFiles++
+		resp.DeletedBytes += o.Size
+	}
+	return resp, nil
+}
+
+// mayDeleteGarbage reports whether the metadata this frontend sees lists every
+// video in the cluster, which deleting unknown files relies on.
+func (s *NetworkVideoContentService) mayDeleteGarbage() bool {
+	return s.leadership == nil || s.sharedMetadata
+}
+
+// knownVideos returns the IDs of all created and reserved videos.
+func (s *NetworkVideoContentService) knownVideos() (map[string]bool, error) {
+	videos, err := s.metadata.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list videos: %w", err)
+	}
+	pending, err := s.metadata.ListPending()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending uploads: %w", err)
+	}
+	known := make(map[string]bool, len(videos)+len(pending))
+	for _, v := range append(videos, pending...) {
+		known[v.Id] = true
+	}
+	return known, nil
+}
+
+func (s *NetworkVideoContentService) deleteOrphan(ctx context.Context, o *proto.OrphanFile) error {
+	client, err := s.clientFor(o.NodeAddress)
+	if err != nil {
+		return err
+	}
+	vid, fname, _ := splitContentPath(o.Path)
+	if _, err := client.DeleteFile(ctx, &proto.DeleteFileRequest{VideoId: vid, Filename: fname}); err != nil {
+		return fmt.Errorf("failed to delete %s from %s: %w", o.Path, o.NodeAddress, err)
+	}
+	log.Printf("DEBUG: Deleted orphaned file %s from %s", o.Path, o.NodeAddress)
+	return nil
+}
+
+// runGarbageCollector collects garbage every gcInterval while this frontend
+// is the cluster leader. If the metadata is not shared it only logs what it
+// finds.
+func (s *NetworkVideoContentService) runGarbageCollector(ctx context.Context) {
+	ticker := time.NewTicker(s.gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		if !s.isLeader() {
+			continue
+		}
+		dryRun := !s.mayDeleteGarbage()
+		resp, err := s.collectGarbage(ctx, s.gcGrace, dryRun)
+		if err != nil {
+			log.Printf("DEBUG: Garbage collection failed: %v", err)
+			continue
+		}
+		if dryRun {
+			log.Printf("DEBUG: Garbage collection scanned %d files and found %d possible orphans; not deleting them because metadata is not shared",
+				resp.ScannedFiles, len(resp.Orphans))
+			continue
+		}
+		log.Printf("DEBUG: Garbage collection scanned %d files, deleted %d orphans (%d bytes), %d errors",
+			resp.ScannedFiles, resp.DeletedFiles, resp.DeletedBytes, len(resp.Errors))
+	}
+}
@@ -1,19 +1,45 @@
 package web
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 type VideoMetadata struct {
 	Id         string
 	UploadedAt time.Time
+	Filename   string // name of the file that was uploaded
+	Slug       string // readable name derived from Filename
 }
 
+// Title is how the video is shown to viewers: its original filename, or its
+// ID for videos uploaded before filenames were kept.
+func (v VideoMetadata) Title() string {
+	if v.Filename != "" {
+		return v.Filename
+	}
+	return v.Id
+}
+
+// ErrVideoExists is returned by Reserve when the video ID is already taken.
+var ErrVideoExists = errors.New("video ID already exists")
+
 type VideoMetadataService interface {
 	Read(id string) (*VideoMetadata, error)
 	List() ([]VideoMetadata, error)
+	// Reserve claims video.Id for an upload that is still being processed.
+	// Reserved videos are not listed or readable until Create is called.
+	Reserve(video VideoMetadata) error
 	Create(videoId string, uploadedAt time.Time) error
+	// ListPending returns the reserved videos that were never created.
+	ListPending() ([]VideoMetadata, error)
+	// Delete removes a video's metadata, whether it is reserved or created.
+	Delete(videoId string) error
 }
 
 type VideoContentService interface {
 	Read(videoId string, filename string) ([]byte, error)
 	Write(videoId string, filename string, data []byte) error
+	// Delete removes every file of a video.
+	Delete(videoId string) error
 }
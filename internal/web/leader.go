@@ -0,0 +1,136 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"tritontube/internal/proto"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// leaderSessionTTL is how long, in seconds, a crashed leader keeps its role
+// before another frontend takes over.
+const leaderSessionTTL = 10
+
+// leadership tracks which frontend of a shared cluster runs rebalances.
+type leadership struct {
+	cli  *clientv3.Client
+	key  string
+	self string // admin address this frontend advertises
+
+	mu       sync.Mutex
+	leader   bool
+	election *concurrency.Election
+	conns    map[string]*grpc.ClientConn
+}
+
+func newLeadership(cli *clientv3.Client, prefix, self string) *leadership {
+	return &leadership{cli: cli, key: prefix + "leader", self: self, conns: make(map[string]*grpc.ClientConn)}
+}
+
+// isLeader reports whether this frontend may run rebalances. A frontend that
+// does not share its cluster is always the leader.
+func (s *NetworkVideoContentService) isLeader() bool {
+	if s.leadership == nil {
+		return true
+	}
+	s.leadership.mu.Lock()
+	defer s.leadership.mu.Unlock()
+	return s.leadership.leader
+}
+
+// campaign runs for leader until ctx is cancelled. On winning it resumes any
+// rebalance that was interrupted, including one a previous leader was running;
+// if its session expires it stops the running rebalance, leaving it for the
+// next leader, and campaigns again.
+func (s *NetworkVideoContentService) campaign(ctx context.Context) {
+	l := s.leadership
+	for ctx.Err() == nil {
+		session, err := concurrency.NewSession(l.cli, concurrency.WithTTL(leaderSessionTTL), concurrency.WithContext(ctx))
+		if err != nil {
+			log.Printf("DEBUG: Failed to create leader session: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		election := concurrency.NewElection(session, l.key)
+		l.mu.Lock()
+		l.election = election
+		l.mu.Unlock()
+
+		if err := election.Campaign(ctx, l.self); err != nil {
+			log.Printf("DEBUG: Leader campaign failed: %v", err)
+			session.Close()
+			continue
+		}
+		log.Printf("DEBUG: %s is now the cluster leader", l.self)
+		l.mu.Lock()
+		l.leader = true
+		l.mu.Unlock()
+		if err := s.resumeInterruptedRebalance(); err != nil {
+			log.Printf("DEBUG: %v", err)
+		}
+
+		select {
+		case <-session.Done():
+			log.Printf("DEBUG: %s lost cluster leadership", l.self)
+		case <-ctx.Done():
+			election.Resign(context.Background())
+		}
+		l.mu.Lock()
+		l.leader = false
+		l.mu.Unlock()
+		s.opMu.Lock()
+		if s.activeOp != nil {
+			s.activeOp.handOver()
+		}
+		s.opMu.Unlock()
+		session.Close()
+	}
+}
+
+// leaderAdmin returns a client for the leader's admin service, or nil if this
+// frontend is the leader and should handle the request itself.
+func (s *NetworkVideoContentService) leaderAdmin(ctx context.Context) (proto.VideoContentAdminServiceClient, error) {
+	if s.isLeader() {
+		return nil, nil
+	}
+	l := s.leadership
+	l.mu.Lock()
+	election := l.election
+	l.mu.Unlock()
+	if election == nil {
+		return nil, status.Error(codes.Unavailable, "cluster leader unknown")
+	}
+	resp, err := election.Leader(ctx)
+	if err != nil {
+		if errors.Is(err, concurrency.ErrElectionNoLeader) {
+			return nil, status.Error(codes.Unavailable, "no cluster leader elected")
+		}
+		return nil, err
+	}
+	addr := string(resp.Kvs[0].Value)
+	if addr == l.self {
+		return nil, status.Error(codes.Unavailable, "cluster leadership is changing")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	conn, ok := l.conns[addr]
+	if !ok {
+		conn, err = grpc.NewClient(addr, grpc.WithTransportCredentials(s.clientCreds))
+		if err != nil {
+			return nil, err
+		}
+		l.conns[addr] = conn
+	}
+	log.Printf("DEBUG: Forwarding admin request to leader %s", addr)
+	return proto.NewVideoContentAdminServiceClient(conn), nil
+}
@@ -0,0 +1,202 @@
+package web
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// The parts of a DASH manifest needed to find its segment files, as written
+// by ffmpeg's dash muxer with -use_template and optionally -use_timeline.
+type mpd struct {
+	MediaPresentationDuration string      `xml:"mediaPresentationDuration,attr"`
+	Periods                   []mpdPeriod `xml:"Period"`
+}
+
+type mpdPeriod struct {
+	Duration       string             `xml:"duration,attr"`
+	AdaptationSets []mpdAdaptationSet `xml:"AdaptationSet"`
+}
+
+type mpdAdaptationSet struct {
+	SegmentTemplate *mpdSegmentTemplate `xml:"SegmentTemplate"`
+	Representations []mpdRepresentation `xml:"Representation"`
+}
+
+type mpdRepresentation struct {
+	ID              string              `xml:"id,attr"`
+	Bandwidth       string              `xml:"bandwidth,attr"`
+	SegmentTemplate *mpdSegmentTemplate `xml:"SegmentTemplate"`
+}
+
+type mpdSegmentTemplate struct {
+	Timescale      uint64       `xml:"timescale,attr"`
+	Duration       uint64       `xml:"duration,attr"`
+	StartNumber    *uint64      `xml:"startNumber,attr"`
+	Initialization string       `xml:"initialization,attr"`
+	Media          string       `xml:"media,attr"`
+	Timeline       *mpdTimeline `xml:"SegmentTimeline"`
+}
+
+type mpdTimeline struct {
+	S []struct {
+		T *uint64 `xml:"t,attr"`
+		D uint64  `xml:"d,attr"`
+		R int64   `xml:"r,attr"`
+	} `xml:"S"`
+}
+
+// manifestSegments returns the file names of every initialization and media
+// segment a manifest references, in manifest order.
+func manifestSegments(data []byte) ([]string, error) {
+	var m mpd
+	if err := xml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	var files []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			files = append(files, name)
+		}
+	}
+	for _, p := range m.Periods {
+		duration := p.Duration
+		if duration == "" {
+			duration = m.MediaPresentationDuration
+		}
+		for _, as := range p.AdaptationSets {
+			for _, r := range as.Representations {
+				tmpl := r.SegmentTemplate
+				if tmpl == nil {
+					tmpl = as.SegmentTemplate
+				}
+				if tmpl == nil {
+					return nil, fmt.Errorf("representation %q has no segment template", r.ID)
+				}
+				names, err := tmpl.files(r, duration)
+				if err != nil {
+					return nil, fmt.Errorf("representation %q: %w", r.ID, err)
+				}
+				for _, name := range names {
+					add(name)
+				}
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.New("manifest references no segments")
+	}
+	return files, nil
+}
+
+// files expands the template for one representation.
+func (t *mpdSegmentTemplate) files(r mpdRepresentation, periodDuration string) ([]string, error) {
+	number := uint64(1)
+	if t.StartNumber != nil {
+		number = *t.StartNumber
+	}
+	var files []string
+	if t.Initialization != "" {
+		files = append(files, expandTemplate(t.Initialization, r, 0, 0))
+	}
+	if t.Media == "" {
+		return files, nil
+	}
+
+	if t.Timeline != nil {
+		var time uint64
+		for i, s := range t.Timeline.S {
+			if s.T != nil {
+				time = *s.T
+			}
+			if s.R < 0 {
+				// Repeats until the next entry's start, or the period's end.
+				if i+1 < len(t.Timeline.S) && t.Timeline.S[i+1].T != nil && s.D > 0 {
+					s.R = int64((*t.Timeline.S[i+1].T-time)/s.D) - 1
+				} else {
+					return nil, errors.New("open-ended segment timeline is not supported")
+				}
+			}
+			for j := int64(0); j <= s.R; j++ {
+				files = append(files, expandTemplate(t.Media, r, number, time))
+				number++
+				time += s.D
+			}
+		}
+		return files, nil
+	}
+
+	if t.Duration == 0 {
+		return nil, errors.New("segment template has neither a timeline nor a duration")
+	}
+	seconds, err := parseMPDDuration(periodDuration)
+	if err != nil {
+		return nil, err
+	}
+	timescale := t.Timescale
+	if timescale == 0 {
+		timescale = 1
+	}
+	count := uint64(math.Ceil(seconds * float64(timescale) / float64(t.Duration)))
+	for i := uint64(0); i < count; i++ {
+		files = append(files, expandTemplate(t.Media, r, number+i, i*t.Duration))
+	}
+	return files, nil
+}
+
+var templateIdentifier = regexp.MustCompile(`\$(RepresentationID|Number|Time|Bandwidth)(?:%0(\d+)d)?\$|\$\$`)
+
+// expandTemplate substitutes the DASH template identifiers in name.
+func expandTemplate(name string, r mpdRepresentation, number, time uint64) string {
+	return templateIdentifier.ReplaceAllStringFunc(name, func(m string) string {
+		if m == "$$" {
+			return "$"
+		}
+		sub := templateIdentifier.FindStringSubmatch(m)
+		var value string
+		switch sub[1] {
+		case "RepresentationID":
+			return r.ID
+		case "Bandwidth":
+			value = r.Bandwidth
+		case "Number":
+			value = strconv.FormatUint(number, 10)
+		case "Time":
+			value = strconv.FormatUint(time, 10)
+		}
+		if width, err := strconv.Atoi(sub[2]); err == nil {
+			for len(value) < width {
+				value = "0" + value
+			}
+		}
+		return value
+	})
+}
+
+var mpdDuration = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseMPDDuration parses an ISO 8601 duration such as "PT0H1M59.89S" into
+// seconds.
+func parseMPDDuration(d string) (float64, error) {
+	m := mpdDuration.FindStringSubmatch(d)
+	if m == nil || d == "P" || d == "PT" {
+		return 0, fmt.Errorf("invalid duration %q", d)
+	}
+	var seconds float64
+	for i, unit := range []float64{86400, 3600, 60, 1} {
+		if m[i+1] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", d)
+		}
+		seconds += v * unit
+	}
+	return seconds, nil
+}
@@ -0,0 +1,356 @@
+package web
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrMembershipConflict is returned when the stored membership changed since
+// it was last loaded, for example because another admin change won the race.
+var ErrMembershipConflict = errors.New("cluster membership changed concurrently")
+
+// clusterMembership is the persisted node list and ring configuration.
+type clusterMembership struct {
+	// Version increases by one with every saved change.
+	Version       int64
+	Nodes         map[string]string // address -> nodeActive, nodeDraining or nodeDrained
+	ShardingKey   ShardingKey
+	SegmentBucket int
+	// DataShards and ParityShards are the erasure coding parameters files
+	// are stored with, both zero in single-copy mode.
+	DataShards   int
+	ParityShards int
+}
+
+// membershipStore persists cluster membership across restarts.
+type membershipStore interface {
+	// Load returns the stored membership, or nil if none has been saved.
+	Load() (*clusterMembership, error)
+	// Save stores m if the stored version still equals m.Version and sets
+	// m.Version to the new version. It returns ErrMembershipConflict otherwise.
+	Save(m *clusterMembership) error
+}
+
+// sqliteMembershipStore keeps membership in the state database.
+type sqliteMembershipStore struct {
+	db *sql.DB
+}
+
+var _ membershipStore = (*sqliteMembershipStore)(nil)
+
+func newSQLiteMembershipStore(db *sql.DB) (*sqliteMembershipStore, error) {
+	createTablesQuery := `
+	CREATE TABLE IF NOT EXISTS cluster_nodes (
+		address TEXT PRIMARY KEY,
+		state TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS cluster_config (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);`
+	if _, err := db.Exec(createTablesQuery); err != nil {
+		return nil, fmt.Errorf("failed to create membership tables: %w", err)
+	}
+	return &sqliteMembershipStore{db: db}, nil
+}
+
+func (st *sqliteMembershipStore) Load() (*clusterMembership, error) {
+	config := make(map[string]string)
+	rows, err := st.db.Query("SELECT key, value FROM cluster_config")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cluster config: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		config[k] = v
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	if _, ok := config["version"]; !ok {
+		return nil, nil
+	}
+
+	m := &clusterMembership{Nodes: make(map[string]string), ShardingKey: ShardingKey(config["sharding_key"])}
+	m.Version, _ = strconv.ParseInt(config["version"], 10, 64)
+	m.SegmentBucket, _ = strconv.Atoi(config["segment_bucket"])
+	m.DataShards, _ = strconv.Atoi(config["data_shards"])
+	m.ParityShards, _ = strconv.Atoi(config["parity_shards"])
+
+	nodeRows, err := st.db.Query("SELECT address, state FROM cluster_nodes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cluster nodes: %w", err)
+	}
+	defer nodeRows.Close()
+	for nodeRows.Next() {
+		var addr, state string
+		if err := nodeRows.Scan(&addr, &state); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		m.Nodes[addr] = state
+	}
+	if err := nodeRows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return m, nil
+}
+
+func (st *sqliteMembershipStore) Save(m *clusterMembership) error {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current int64
+	err = tx.QueryRow("SELECT CAST(value AS INTEGER) FROM cluster_config WHERE key = 'version'").Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to query membership version: %w", err)
+	}
+	if current != m.Version {
+		return ErrMembershipConflict
+	}
+
+	if _, err := tx.Exec("DELETE FROM cluster_nodes"); err != nil {
+		return fmt.Errorf("failed to clear cluster nodes: %w", err)
+	}
+	for addr, state := range m.Nodes {
+		if _, err := tx.Exec("INSERT INTO cluster_nodes (address, state) VALUES (?, ?)", addr, state); err != nil {
+			return fmt.Errorf("failed to insert cluster node: %w", err)
+		}
+	}
+	config := map[string]string{
+		"version":        strconv.FormatInt(m.Version+1, 10),
+		"sharding_key":   string(m.ShardingKey),
+		"segment_bucket": strconv.Itoa(m.SegmentBucket),
+		"data_shards":    strconv.Itoa(m.DataShards),
+		"parity_shards":  strconv.Itoa(m.ParityShards),
+	}
+	for k, v := range config {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO cluster_config (key, value) VALUES (?, ?)", k, v); err != nil {
+			return fmt.Errorf("failed to update cluster config: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit membership: %w", err)
+	}
+	m.Version++
+	return nil
+}
+
+// loadMembership restores the persisted membership, or seeds it from the
+// command-line nodes on first start. Once membership has been persisted it is
+// authoritative: it returns the seed nodes that are not part of it, which
+// joinSeeds adds with a rebalance unless WithJoinSeeds(false) was given.
+func (s *NetworkVideoContentService) loadMembership(seeds []string) ([]string, error) {
+	m, err := s.membership.Load()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m == nil {
+		for _, n := range seeds {
+			if err := s.connectNode(n); err != nil {
+				return nil, err
+			}
+		}
+		s.rebuildRing()
+		err := s.commitMembership(nil)
+		if errors.Is(err, ErrMembershipConflict) {
+			// Another frontend seeded the shared membership first and
+			// commitMembership has already switched to it.
+			latest, lerr := s.membership.Load()
+			if lerr != nil || latest == nil {
+				return nil, lerr
+			}
+			if err := s.checkMembershipConfig(latest); err != nil {
+				return nil, err
+			}
+			return s.missingSeeds(seeds, latest), nil
+		}
+		return nil, err
+	}
+
+	if err := s.checkMembershipConfig(m); err != nil {
+		return nil, err
+	}
+	log.Printf("DEBUG: Loaded membership version %d: %v", m.Version, m.Nodes)
+	if err := s.syncMembership(m); err != nil {
+		return nil, err
+	}
+	return s.missingSeeds(seeds, m), nil
+}
+
+// missingSeeds returns the seeds that m does not know, reporting them.
+func (s *NetworkVideoContentService) missingSeeds(seeds []string, m *clusterMembership) []string {
+	var missing []string
+	for _, n := range seeds {
+		if _, ok := m.Nodes[n]; ok {
+			continue
+		}
+		if s.joinSeeds {
+			log.Printf("Seed node %s is not in the persisted membership (version %d); adding it", n, m.Version)
+		} else {
+			log.Printf("Seed node %s is not in the persisted membership (version %d); ignoring it, use admin add to add it", n, m.Version)
+		}
+		missing = append(missing, n)
+	}
+	return missing
+}
+
+// addSeeds adds the seed nodes missing from the persisted membership one at a
+// time through AddNode, so each is rebalanced like an admin add. Adds that
+// cannot start yet, because a rebalance is running or the cluster has no
+// leader, are retried until ctx is cancelled.
+func (s *NetworkVideoContentService) addSeeds(ctx context.Context, seeds []string) {
+	for _, addr := range seeds {
+		for ctx.Err() == nil {
+			s.mu.RLock()
+			_, member := s.clients[addr]
+			_, draining := s.draining[addr]
+			s.mu.RUnlock()
+			if member || draining {
+				// Added, or changed since, by someone else.
+				break
+			}
+			// A seed that is down or mistyped must not join the ring.
+			if err := s.pingNode(ctx, addr); err != nil {
+				log.Printf("DEBUG: Seed node %s is not answering yet: %v", addr, err)
+				select {
+				case <-time.After(registryRetryInterval):
+				case <-ctx.Done():
+				}
+				continue
+			}
+			resp, err := s.AddNode(ctx, &proto.AddNodeRequest{NodeAddress: addr})
+			if err == nil {
+				log.Printf("DEBUG: Adding seed node %s (rebalance %s)", addr, resp.GetOperationId())
+				break
+			}
+			if c := status.Code(err); c != codes.FailedPrecondition && c != codes.Unavailable && c != codes.Aborted {
+				log.Printf("Failed to add seed node %s: %v", addr, err)
+				break
+			}
+			log.Printf("DEBUG: Cannot add seed node %s yet: %v", addr, err)
+			select {
+			case <-time.After(registryRetryInterval):
+			case <-ctx.Done():
+			}
+		}
+	}
+}
+
+// pingNode checks that a storage node answers at addr.
+func (s *NetworkVideoContentService) pingNode(ctx context.Context, addr string) error {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(s.clientCreds))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	_, err = proto.NewVideoStorageServiceClient(conn).GetStats(ctx, &proto.GetStatsRequest{})
+	return err
+}
+
+// checkMembershipConfig fails if m was saved with a ring or storage mode
+// other than this service's, under which the stored files cannot be found.
+func (s *NetworkVideoContentService) checkMembershipConfig(m *clusterMembership) error {
+	if m.ShardingKey != s.placement.key ||
+		(m.ShardingKey == ShardBySegment && m.SegmentBucket != s.placement.bucketSize) {
+		return fmt.Errorf("persisted ring shards by %s (bucket %d), not %s (bucket %d); changing placement needs a migration",
+			m.ShardingKey, m.SegmentBucket, s.placement.key, s.placement.bucketSize)
+	}
+	dataShards, parityShards := s.erasureShards()
+	if m.DataShards != dataShards || m.ParityShards != parityShards {
+		return fmt.Errorf("persisted files are stored %s, not %s; changing the storage mode needs a migration",
+			storageMode(m.DataShards, m.ParityShards), storageMode(dataShards, parityShards))
+	}
+	return nil
+}
+
+// commitMembership saves the current membership with change applied, failing
+// with ErrMembershipConflict if someone else saved a newer version first. The
+// caller holds s.mu and applies the change in memory once this succeeds.
+func (s *NetworkVideoContentService) commitMembership(change func(nodes map[string]string)) error {
+	nodes := make(map[string]string)
+	for addr := range s.clients {
+		nodes[addr] = nodeActive
+	}
+	for addr, state := range s.draining {
+		nodes[addr] = state
+	}
+	if change != nil {
+		change(nodes)
+	}
+
+	m := &clusterMembership{
+		Version:       s.membershipVersion,
+		Nodes:         nodes,
+		ShardingKey:   s.placement.key,
+		SegmentBucket: s.placement.bucketSize,
+	}
+	m.DataShards, m.ParityShards = s.erasureShards()
+	if err := s.membership.Save(m); err != nil {
+		if errors.Is(err, ErrMembershipConflict) {
+			// Pick up the winning change so a retry starts from it.
+			if latest, lerr := s.membership.Load(); lerr == nil && latest != nil {
+				s.syncMembership(latest)
+			}
+		}
+		return err
+	}
+	s.membershipVersion = m.Version
+	log.Printf("DEBUG: Saved membership version %d: %v", m.Version, nodes)
+	return nil
+}
+
+// syncMembership makes the in-memory node set and ring match m. The caller
+// holds s.mu.
+func (s *NetworkVideoContentService) syncMembership(m *clusterMembership) error {
+	for addr, state := range m.Nodes {
+		if state == nodeActive {
+			if err := s.connectNode(addr); err != nil {
+				return err
+			}
+			delete(s.draining, addr)
+			continue
+		}
+		if _, err := s.dialNode(addr); err != nil {
+			return err
+		}
+		delete(s.clients, addr)
+		s.draining[addr] = state
+	}
+	for _, addr := range s.members() {
+		if _, ok := m.Nodes[addr]; !ok {
+			s.disconnectNode(addr)
+		}
+	}
+	for addr := range s.draining {
+		if _, ok := m.Nodes[addr]; !ok {
+			delete(s.draining, addr)
+			s.disconnectNode(addr)
+		}
+	}
+	s.membershipVersion = m.Version
+	s.rebuildRing()
+	return nil
+}
@@ -0,0 +1,116 @@
+package web
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMembershipRefusesStorageModeChange(t *testing.T) {
+	nodes := []string{startStorageNode(t), startStorageNode(t), startStorageNode(t)}
+	db := filepath.Join(t.TempDir(), "state.db")
+	if _, err := NewNetworkVideoContentService(freeAddr(t), nodes, WithStateDB(db), WithErasureCoding(2, 1)); err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts []NetworkOption
+		ok   bool
+	}{
+		{"same mode", []NetworkOption{WithErasureCoding(2, 1)}, true},
+		{"single copy", nil, false},
+		{"more data shards", []NetworkOption{WithErasureCoding(3, 1)}, false},
+		{"more parity shards", []NetworkOption{WithErasureCoding(2, 2)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]NetworkOption{WithStateDB(db)}, tt.opts...)
+			_, err := NewNetworkVideoContentService(freeAddr(t), nodes, opts...)
+			if tt.ok && err != nil {
+				t.Fatalf("restart: %v", err)
+			}
+			if !tt.ok && (err == nil || !strings.Contains(err.Error(), "storage mode")) {
+				t.Fatalf("restart = %v, want a storage mode mismatch", err)
+			}
+		})
+	}
+}
+
+func TestMembershipRefusesErasureCodingOnSingleCopyCluster(t *testing.T) {
+	nodes := []string{startStorageNode(t), startStorageNode(t), startStorageNode(t)}
+	db := filepath.Join(t.TempDir(), "state.db")
+	if _, err := NewNetworkVideoContentService(freeAddr(t), nodes, WithStateDB(db)); err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	_, err := NewNetworkVideoContentService(freeAddr(t), nodes, WithStateDB(db), WithErasureCoding(2, 1))
+	if err == nil || !strings.Contains(err.Error(), "storage mode") {
+		t.Fatalf("restart with erasure coding = %v, want a storage mode mismatch", err)
+	}
+}
+
+func TestMembershipAddsNewSeeds(t *testing.T) {
+	seed, added := startStorageNode(t), startStorageNode(t)
+	db := filepath.Join(t.TempDir(), "state.db")
+	if _, err := NewNetworkVideoContentService(freeAddr(t), []string{seed}, WithStateDB(db)); err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+
+	s, err := NewNetworkVideoContentService(freeAddr(t), []string{seed, added}, WithStateDB(db))
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	waitFor(t, "the new seed to join", func() bool { return nodeState(t, s, added) == nodeActive })
+	waitIdle(t, s)
+}
+
+func TestMembershipAddsSeedOnlyOnceItAnswers(t *testing.T) {
+	seed, late := startStorageNode(t), freeAddr(t)
+	db := filepath.Join(t.TempDir(), "state.db")
+	if _, err := NewNetworkVideoContentService(freeAddr(t), []string{seed}, WithStateDB(db)); err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+
+	s, err := NewNetworkVideoContentService(freeAddr(t), []string{seed, late}, WithStateDB(db))
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	s.mu.RLock()
+	version := s.membershipVersion
+	s.mu.RUnlock()
+	time.Sleep(2 * registryRetryInterval)
+	if got := nodeState(t, s, late); got != "" {
+		t.Errorf("unreachable seed state = %q, want it not to be a member", got)
+	}
+	s.mu.RLock()
+	got := s.membershipVersion
+	s.mu.RUnlock()
+	if got != version {
+		t.Errorf("membership version = %d, want %d with the unreachable seed left out", got, version)
+	}
+
+	startStorageNodeAt(t, late)
+	waitFor(t, "the seed to join once it answers", func() bool { return nodeState(t, s, late) == nodeActive })
+	waitIdle(t, s)
+}
+
+func TestMembershipIgnoresNewSeedsWhenPersistedWins(t *testing.T) {
+	seed, ignored := startStorageNode(t), startStorageNode(t)
+	db := filepath.Join(t.TempDir(), "state.db")
+	if _, err := NewNetworkVideoContentService(freeAddr(t), []string{seed}, WithStateDB(db)); err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+
+	s, err := NewNetworkVideoContentService(freeAddr(t), []string{seed, ignored}, WithStateDB(db), WithJoinSeeds(false))
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	time.Sleep(2 * registryRetryInterval)
+	if got := nodeState(t, s, ignored); got != "" {
+		t.Errorf("ignored seed state = %q, want it not to be a member", got)
+	}
+	if got := nodeState(t, s, seed); got != nodeActive {
+		t.Errorf("seed state = %q, want %q", got, nodeActive)
+	}
+}
@@ -10,29 +10,132 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"slices"
 	"sort"
-	"strings"
 	"sync"
+	"time"
 
+	"tritontube/internal/pathcheck"
 	"tritontube/internal/proto"
+	"tritontube/internal/tlsconfig"
 
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
 )
 
 // NetworkVideoContentService implements VideoContentService using a network of nodes.
 type NetworkVideoContentService struct {
 	proto.UnimplementedVideoContentAdminServiceServer
 
-	mu      sync.RWMutex
-	clients map[string]proto.VideoStorageServiceClient
-	conns   map[string]*grpc.ClientConn
-	ring    []ringEntry
+	mu       sync.RWMutex
+	clients  map[string]proto.VideoStorageServiceClient // ring members
+	conns    map[string]*grpc.ClientConn                // members plus nodes still being migrated away from
+	draining map[string]string                          // nodes out of the ring that still serve reads
+	ring     hashRing
+	// prevRing is the ring before the last membership change. Reads fall back
+	// to it until that change's rebalance has completed.
+	prevRing hashRing
+	// settledVersion is the latest membership version the cluster leader has
+	// finished rebalancing for.
+	settledVersion int64
+
+	placement placement
+
+	retry      RetryPolicy
+	hedgeAfter time.Duration
+
+	tlsConfig   *tlsconfig.Config
+	clientCreds credentials.TransportCredentials
+	adminAuth   *AdminAuth
+	audit       *log.Logger
+
+	stateDBPath       string
+	joinSeeds         bool
+	membership        membershipStore
+	membershipVersion int64
+	rebalanceWorkers  int
+	rebalanceRate     int64
+	rebalances        rebalanceStore
+	throttle          *throttle
+	opMu              sync.Mutex
+	activeOp          *rebalanceOp
+
+	registryEndpoints []string
+	registryPrefix    string
+	registry          *nodeRegistry
+
+	clusterEndpoints []string
+	clusterPrefix    string
+	advertiseAdmin   string
+	leadership       *leadership
+
+	metadata       VideoMetadataService
+	sharedMetadata bool
+	gcGrace        time.Duration
+	gcInterval     time.Duration
+
+	ecDataShards   int
+	ecParityShards int
+	ecScanInterval time.Duration
+	erasure        *erasure // nil in single-copy mode
 }
 
-type ringEntry struct {
-	hash uint64
-	addr string
+// NetworkOption configures optional behaviour of a NetworkVideoContentService.
+type NetworkOption func(*NetworkVideoContentService)
+
+// WithShardingKey selects how files are placed on the ring. bucketSize is the
+// number of segments per bucket for ShardBySegment and is ignored otherwise.
+func WithShardingKey(key ShardingKey, bucketSize int) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		if bucketSize <= 0 {
+			bucketSize = DefaultSegmentBucketSize
+		}
+		s.placement = placement{key: key, bucketSize: bucketSize}
+	}
+}
+
+// WithStateDB stores membership and rebalance operations in the SQLite database
+// at path so they survive a restart. Without it they are kept in memory only.
+func WithStateDB(path string) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.stateDBPath = path
+	}
+}
+
+// WithJoinSeeds controls what happens to seed nodes that the persisted
+// membership does not include. By default they are added with a rebalance, as
+// an admin add would. With join false the persisted membership wins and they
+// are only logged, so a node removed through the admin service stays removed
+// even if it is still listed on the command line.
+func WithJoinSeeds(join bool) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.joinSeeds = join
+	}
+}
+
+// WithRebalanceWorkers sets how many files a rebalance moves in parallel.
+func WithRebalanceWorkers(n int) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		if n > 0 {
+			s.rebalanceWorkers = n
+		}
+	}
+}
+
+// WithRebalanceRateLimit caps rebalance traffic at bytesPerSec. Zero means unlimited.
+func WithRebalanceRateLimit(bytesPerSec int64) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.rebalanceRate = bytesPerSec
+	}
+}
+
+// WithTLS secures storage and admin traffic with mutual TLS using cfg. Storage
+// nodes and admin clients must present certificates signed by cfg's CA.
+func WithTLS(cfg *tlsconfig.Config) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.tlsConfig = cfg
+	}
 }
 
 // Uncomment the following line to ensure NetworkVideoContentService implements VideoContentService
@@ -43,42 +146,187 @@ func hashStringToUint64(s string) uint64 {
 	return binary.BigEndian.Uint64(sum[:8])
 }
 
-func NewNetworkVideoContentService(adminAddr string, nodes []string) (*NetworkVideoContentService, error) {
+type ringEntry struct {
+	hash uint64
+	addr string
+}
+
+// hashRing is a consistent-hash ring sorted by hash.
+type hashRing []ringEntry
+
+func newHashRing(addrs []string) hashRing {
+	r := make(hashRing, 0, len(addrs))
+	for _, addr := range addrs {
+		r = append(r, ringEntry{hash: hashStringToUint64(addr), addr: addr})
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].hash < r[j].hash })
+	return r
+}
+
+// lookup returns the node that owns key, or "" if the ring is empty.
+func (r hashRing) lookup(key string) string {
+	if len(r) == 0 {
+		return ""
+	}
+	h := hashStringToUint64(key)
+	i := sort.Search(len(r), func(i int) bool { return r[i].hash >= h })
+	if i == len(r) {
+		i = 0
+	}
+	return r[i].addr
+}
+
+// lookupN returns up to n distinct nodes for key, starting with its owner and
+// continuing clockwise round the ring.
+func (r hashRing) lookupN(key string, n int) []string {
+	if n > len(r) {
+		n = len(r)
+	}
+	if n == 0 {
+		return nil
+	}
+	h := hashStringToUint64(key)
+	start := sort.Search(len(r), func(i int) bool { return r[i].hash >= h })
+	addrs := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		addrs = append(addrs, r[(start+i)%len(r)].addr)
+	}
+	return addrs
+}
+
+func NewNetworkVideoContentService(adminAddr string, nodes []string, opts ...NetworkOption) (*NetworkVideoContentService, error) {
 	svc := &NetworkVideoContentService{
-		clients: make(map[string]proto.VideoStorageServiceClient),
-		conns:   make(map[string]*grpc.ClientConn),
+		clients:          make(map[string]proto.VideoStorageServiceClient),
+		conns:            make(map[string]*grpc.ClientConn),
+		draining:         make(map[string]string),
+		placement:        placement{key: ShardByFile, bucketSize: DefaultSegmentBucketSize},
+		stateDBPath:      ":memory:",
+		joinSeeds:        true,
+		rebalanceWorkers: 4,
+		retry:            DefaultRetryPolicy,
+		audit:            log.Default(),
+		gcGrace:          DefaultGCGrace,
+		ecScanInterval:   DefaultShardScanInterval,
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	svc.throttle = &throttle{rate: svc.rebalanceRate}
+	if svc.ecDataShards > 0 {
+		var err error
+		if svc.erasure, err = newErasure(svc.ecDataShards, svc.ecParityShards); err != nil {
+			return nil, err
+		}
+	}
+	serverCreds, err := svc.tlsConfig.ServerCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if svc.clientCreds, err = svc.tlsConfig.ClientCredentials(); err != nil {
+		return nil, err
 	}
 
-	log.Printf("DEBUG: Creating NetworkVideoContentService with admin addr %s and nodes %v", adminAddr, nodes)
+	log.Printf("DEBUG: Creating NetworkVideoContentService with admin addr %s, nodes %v, sharding by %s", adminAddr, nodes, svc.placement.key)
 
-	for _, n := range nodes {
-		if err := svc.connectNode(n); err != nil {
+	db, err := openStateDB(svc.stateDBPath)
+	if err != nil {
+		return nil, err
+	}
+	var shared *etcdMembershipStore
+	if len(svc.clusterEndpoints) > 0 {
+		cli, err := clientv3.New(clientv3.Config{Endpoints: svc.clusterEndpoints, DialTimeout: 5 * time.Second})
+		if err != nil {
 			return nil, err
 		}
+		shared = newEtcdMembershipStore(cli, svc.clusterPrefix)
+		svc.membership = shared
+		if svc.advertiseAdmin == "" {
+			svc.advertiseAdmin = adminAddr
+		}
+		svc.leadership = newLeadership(cli, svc.clusterPrefix, svc.advertiseAdmin)
+		svc.rebalances = newEtcdRebalanceStore(cli, svc.clusterPrefix)
+	} else {
+		if svc.membership, err = newSQLiteMembershipStore(db); err != nil {
+			return nil, err
+		}
+		if svc.rebalances, err = newSQLiteRebalanceStore(db); err != nil {
+			return nil, err
+		}
+	}
+
+	missingSeeds, err := svc.loadMembership(nodes)
+	if err != nil {
+		return nil, err
 	}
-	svc.rebuildRing()
 
 	log.Printf("DEBUG: Initial ring: %v", svc.ring)
 
+	if shared == nil {
+		if err := svc.resumeInterruptedRebalance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(svc.registryEndpoints) > 0 {
+		if svc.registry, err = newNodeRegistry(svc.registryEndpoints, svc.registryPrefix); err != nil {
+			return nil, err
+		}
+		go svc.watchRegistry(context.Background(), svc.registry)
+	}
+
 	lis, err := net.Listen("tcp", adminAddr)
 	if err != nil {
 		return nil, err
 	}
-	server := grpc.NewServer()
+	server := grpc.NewServer(
+		grpc.Creds(serverCreds),
+		grpc.UnaryInterceptor(svc.adminUnaryInterceptor),
+		grpc.StreamInterceptor(svc.adminStreamInterceptor),
+	)
 	proto.RegisterVideoContentAdminServiceServer(server, svc)
 	go server.Serve(lis)
+
+	if shared != nil {
+		go svc.watchMembership(context.Background(), shared)
+		go svc.campaign(context.Background())
+	}
+	if svc.joinSeeds && len(missingSeeds) > 0 {
+		go svc.addSeeds(context.Background(), missingSeeds)
+	}
+	if svc.metadata != nil && svc.gcInterval > 0 {
+		go svc.runGarbageCollector(context.Background())
+	}
+	if svc.erasure != nil {
+		go svc.runShardRepairs(context.Background())
+		if svc.ecScanInterval > 0 {
+			go svc.runShardScans(context.Background())
+		}
+	}
 	return svc, nil
 }
 
+// dialNode returns the connection to addr, opening one if needed. The caller
+// holds s.mu.
+func (s *NetworkVideoContentService) dialNode(addr string) (*grpc.ClientConn, error) {
+	if conn, ok := s.conns[addr]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(s.clientCreds))
+	if err != nil {
+		return nil, err
+	}
+	s.conns[addr] = conn
+	return conn, nil
+}
+
 func (s *NetworkVideoContentService) connectNode(addr string) error {
 	if _, ok := s.clients[addr]; ok {
 		return nil
 	}
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := s.dialNode(addr)
 	if err != nil {
 		return err
 	}
-	s.conns[addr] = conn
 	s.clients[addr] = proto.NewVideoStorageServiceClient(conn)
 	log.Printf("DEBUG: Connected to node %s", addr)
 	return nil
@@ -93,42 +341,111 @@ func (s *NetworkVideoContentService) disconnectNode(addr string) {
 	log.Printf("DEBUG: Disconnected from node %s", addr)
 }
 
-func (s *NetworkVideoContentService) rebuildRing() {
-	s.ring = s.ring[:0]
+// clientFor returns a client for addr whether or not it is a ring member,
+// dialing it if there is no open connection.
+func (s *NetworkVideoContentService) clientFor(addr string) (proto.VideoStorageServiceClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.clients[addr]; ok {
+		return c, nil
+	}
+	conn, err := s.dialNode(addr)
+	if err != nil {
+		return nil, err
+	}
+	return proto.NewVideoStorageServiceClient(conn), nil
+}
+
+// tempClient returns a client for addr without keeping a connection to it:
+// the cached one if addr is already connected, otherwise a new connection
+// that release closes. Dry runs use it for nodes that may never join.
+func (s *NetworkVideoContentService) tempClient(addr string) (client proto.VideoStorageServiceClient, release func(), err error) {
+	s.mu.RLock()
+	if c, ok := s.clients[addr]; ok {
+		s.mu.RUnlock()
+		return c, func() {}, nil
+	}
+	if conn, ok := s.conns[addr]; ok {
+		s.mu.RUnlock()
+		return proto.NewVideoStorageServiceClient(conn), func() {}, nil
+	}
+	s.mu.RUnlock()
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(s.clientCreds))
+	if err != nil {
+		return nil, nil, err
+	}
+	return proto.NewVideoStorageServiceClient(conn), func() { conn.Close() }, nil
+}
+
+func (s *NetworkVideoContentService) members() []string {
+	var addrs []string
 	for addr := range s.clients {
-		hash := hashStringToUint64(addr)
-		s.ring = append(s.ring, ringEntry{hash: hash, addr: addr})
-		log.Printf("DEBUG: Added to ring: %s (hash: %d)", addr, hash)
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+func (s *NetworkVideoContentService) rebuildRing() {
+	s.ring = newHashRing(s.members())
+	for _, e := range s.ring {
+		log.Printf("DEBUG: Added to ring: %s (hash: %d)", e.addr, e.hash)
 	}
-	sort.Slice(s.ring, func(i, j int) bool { return s.ring[i].hash < s.ring[j].hash })
 	log.Printf("DEBUG: Ring rebuilt with %d nodes", len(s.ring))
 }
 
 func (s *NetworkVideoContentService) pickNode(key string) (string, proto.VideoStorageServiceClient) {
-	h := hashStringToUint64(key)
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	if len(s.ring) == 0 {
+	addr := s.ring.lookup(key)
+	if addr == "" {
 		log.Printf("DEBUG: pickNode(%s): no nodes in ring", key)
 		return "", nil
 	}
-	i := sort.Search(len(s.ring), func(i int) bool { return s.ring[i].hash >= h })
-	if i == len(s.ring) {
-		i = 0
-	}
-	addr := s.ring[i].addr
-	log.Printf("DEBUG: pickNode(%s): hash=%d -> node %s", key, h, addr)
+	log.Printf("DEBUG: pickNode(%s): hash=%d -> node %s", key, hashStringToUint64(key), addr)
 	return addr, s.clients[addr]
 }
 
+// ownerOf returns the node that should hold videoId/filename under the current ring.
+func (s *NetworkVideoContentService) ownerOf(videoId, filename string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ownerIn(s.ring, videoId, filename)
+}
+
+// ownerIn returns the node that ring places videoId/filename on. An erasure
+// coded shard goes to the node its index selects among its file's owners.
+func (s *NetworkVideoContentService) ownerIn(ring hashRing, videoId, filename string) string {
+	if s.erasure != nil {
+		if base, i, ok := parseShardName(filename); ok {
+			owners := ring.lookupN(s.placement.keyFor(videoId, base), s.erasure.shards())
+			if i < len(owners) {
+				return owners[i]
+			}
+			return ""
+		}
+	}
+	return ring.lookup(s.placement.keyFor(videoId, filename))
+}
+
 func (s *NetworkVideoContentService) Write(videoId, filename string, data []byte) error {
+	if err := pathcheck.Validate(videoId, filename); err != nil {
+		return err
+	}
 	key := videoId + "/" + filename
-	addr, client := s.pickNode(key)
+	if s.erasure != nil {
+		log.Printf("DEBUG: Writing %s as %d+%d shards (%d bytes)", key, s.erasure.dataShards, s.erasure.parityShards, len(data))
+		return s.writeErasure(context.Background(), videoId, filename, data)
+	}
+	addr, client := s.pickNode(s.placement.keyFor(videoId, filename))
 	if client == nil {
 		return errors.New("no storage nodes available")
 	}
 	log.Printf("DEBUG: Writing %s to node %s (%d bytes)", key, addr, len(data))
-	_, err := client.WriteFile(context.Background(), &proto.WriteFileRequest{VideoId: videoId, Filename: filename, Data: data})
+	err := s.withRetry(context.Background(), "write "+key+" to "+addr, func(ctx context.Context) error {
+		_, err := client.WriteFile(ctx, &proto.WriteFileRequest{VideoId: videoId, Filename: filename, Data: data})
+		return err
+	})
 	if err != nil {
 		log.Printf("DEBUG: Write failed for %s: %v", key, err)
 	}
@@ -136,167 +453,164 @@ func (s *NetworkVideoContentService) Write(videoId, filename string, data []byte
 }
 
 func (s *NetworkVideoContentService) Read(videoId, filename string) ([]byte, error) {
+	if err := pathcheck.Validate(videoId, filename); err != nil {
+		return nil, err
+	}
 	key := videoId + "/" + filename
-	addr, client := s.pickNode(key)
+	if s.erasure != nil {
+		return s.readErasure(context.Background(), videoId, filename)
+	}
+	addr, client := s.pickNode(s.placement.keyFor(videoId, filename))
 	if client == nil {
 		log.Printf("DEBUG: Read failed for %s: no nodes available", key)
 		return nil, errors.New("no storage nodes available")
 	}
 	log.Printf("DEBUG: Reading %s from node %s", key, addr)
-	resp, err := client.ReadFile(context.Background(), &proto.ReadFileRequest{VideoId: videoId, Filename: filename})
+	data, hedged, err := s.hedgedRead(context.Background(), addr, client, videoId, filename)
 	if err != nil {
 		log.Printf("DEBUG: Read failed for %s from %s: %v", key, addr, err)
+		if data, ok := s.readPrevious(videoId, filename, addr, hedged); ok {
+			return data, nil
+		}
 		return nil, err
 	}
-	log.Printf("DEBUG: Read successful for %s: %d bytes", key, len(resp.Data))
-	return resp.Data, nil
+	log.Printf("DEBUG: Read successful for %s: %d bytes", key, len(data))
+	return data, nil
 }
 
-func (s *NetworkVideoContentService) ListNodes(ctx context.Context, req *proto.ListNodesRequest) (*proto.ListNodesResponse, error) {
+// Delete removes every file of videoId from every node, including nodes that
+// are draining and may still hold some of them.
+func (s *NetworkVideoContentService) Delete(videoId string) error {
+	if err := pathcheck.ValidateVideoID(videoId); err != nil {
+		return err
+	}
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-	var nodes []string
-	for addr := range s.clients {
-		nodes = append(nodes, addr)
+	addrs := s.members()
+	for addr := range s.draining {
+		addrs = append(addrs, addr)
 	}
-	sort.Strings(nodes)
-	log.Printf("DEBUG: ListNodes returning: %v", nodes)
-	return &proto.ListNodesResponse{Nodes: nodes}, nil
-}
+	s.mu.RUnlock()
 
-func (s *NetworkVideoContentService) AddNode(ctx context.Context, req *proto.AddNodeRequest) (*proto.AddNodeResponse, error) {
-	addr := req.GetNodeAddress()
-	log.Printf("DEBUG: AddNode called for %s", addr)
-
-	s.mu.Lock()
-	if err := s.connectNode(addr); err != nil {
-		s.mu.Unlock()
-		return nil, err
+	ctx := context.Background()
+	var errs []error
+	for _, addr := range addrs {
+		if err := s.deleteVideoFrom(ctx, addr, videoId); err != nil {
+			errs = append(errs, err)
+		}
 	}
+	return errors.Join(errs...)
+}
 
-	nodes := make(map[string][]string)
-	for a, c := range s.clients {
-		resp, err := c.ListFiles(ctx, &proto.ListFilesRequest{})
-		if err != nil {
-			log.Printf("DEBUG: Failed to list files from %s: %v", a, err)
-			continue
+func (s *NetworkVideoContentService) deleteVideoFrom(ctx context.Context, addr, videoId string) error {
+	client, err := s.clientFor(addr)
+	if err != nil {
+		return err
+	}
+	var filenames []string
+	err = s.forEachFile(ctx, addr, videoId+"/", func(f *proto.FileInfo) error {
+		if _, fname, ok := splitContentPath(f.Path); ok {
+			filenames = append(filenames, fname)
 		}
-		nodes[a] = append([]string(nil), resp.Paths...)
-		log.Printf("DEBUG: Node %s has files: %v", a, resp.Paths)
-	}
-	s.rebuildRing()
-	s.mu.Unlock()
-
-	migrated := 0
-	for nodeAddr, files := range nodes {
-		c := s.clients[nodeAddr]
-		for _, p := range files {
-			parts := strings.SplitN(p, "/", 2)
-			if len(parts) != 2 {
-				continue
-			}
-			vid, fname := parts[0], parts[1]
-			targetAddr, targetClient := s.pickNode(vid + "/" + fname)
-			if targetAddr == nodeAddr {
-				log.Printf("DEBUG: File %s already on correct node %s", p, nodeAddr)
-				continue
-			}
-			log.Printf("DEBUG: Migrating %s from %s to %s", p, nodeAddr, targetAddr)
-
-			dataResp, err := c.ReadFile(ctx, &proto.ReadFileRequest{VideoId: vid, Filename: fname})
-			if err != nil {
-				log.Printf("DEBUG: Failed to read %s from %s: %v", p, nodeAddr, err)
-				continue
-			}
-			_, err = targetClient.WriteFile(ctx, &proto.WriteFileRequest{VideoId: vid, Filename: fname, Data: dataResp.Data})
-			if err == nil {
-				c.DeleteFile(ctx, &proto.DeleteFileRequest{VideoId: vid, Filename: fname})
-				migrated++
-				log.Printf("DEBUG: Successfully migrated %s", p)
-			} else {
-				log.Printf("DEBUG: Failed to write %s to %s: %v", p, targetAddr, err)
-			}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, fname := range filenames {
+		err := s.withRetry(ctx, "delete "+videoId+"/"+fname+" from "+addr, func(ctx context.Context) error {
+			_, err := client.DeleteFile(ctx, &proto.DeleteFileRequest{VideoId: videoId, Filename: fname})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete %s/%s from %s: %w", videoId, fname, addr, err)
 		}
 	}
-
-	log.Printf("DEBUG: AddNode completed, migrated %d files", migrated)
-	return &proto.AddNodeResponse{MigratedFileCount: int32(migrated)}, nil
+	log.Printf("DEBUG: Deleted %d files of %s from %s", len(filenames), videoId, addr)
+	return nil
 }
 
-func (s *NetworkVideoContentService) RemoveNode(ctx context.Context, req *proto.RemoveNodeRequest) (*proto.RemoveNodeResponse, error) {
-	addr := req.GetNodeAddress()
-	log.Printf("DEBUG: RemoveNode called for %s", addr)
+// previousOwner returns the owner of videoId/filename under the previous ring,
+// or "" if no rebalance is in progress.
+func (s *NetworkVideoContentService) previousOwner(videoId, filename string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ownerIn(s.prevRing, videoId, filename)
+}
 
-	s.mu.Lock()
-	client, ok := s.clients[addr]
-	if !ok {
-		s.mu.Unlock()
-		return nil, fmt.Errorf("node not found")
+// readPrevious retries a read on the file's owner under the previous ring, where
+// it may still live while a rebalance is moving it, unless that node has been
+// tried already.
+func (s *NetworkVideoContentService) readPrevious(videoId, filename string, tried ...string) ([]byte, bool) {
+	prev := s.previousOwner(videoId, filename)
+	if prev == "" || slices.Contains(tried, prev) {
+		return nil, false
 	}
-
-	resp, err := client.ListFiles(ctx, &proto.ListFilesRequest{})
+	client, err := s.clientFor(prev)
 	if err != nil {
-		s.mu.Unlock()
-		return nil, err
+		return nil, false
 	}
-	files := append([]string(nil), resp.Paths...)
-	log.Printf("DEBUG: Node %s has %d files: %v", addr, len(files), files)
-
-	log.Printf("DEBUG: Current ring before removal:")
-	for _, entry := range s.ring {
-		log.Printf("  %s (hash: %d)", entry.addr, entry.hash)
+	data, err := s.readFile(context.Background(), prev, client, videoId, filename)
+	if err != nil {
+		log.Printf("DEBUG: Fallback read failed for %s/%s from %s: %v", videoId, filename, prev, err)
+		return nil, false
 	}
+	log.Printf("DEBUG: Read %s/%s from previous owner %s", videoId, filename, prev)
+	return data, true
+}
 
-	delete(s.clients, addr)
-	s.rebuildRing()
-
-	log.Printf("DEBUG: Ring after removal:")
-	for _, entry := range s.ring {
-		log.Printf("  %s (hash: %d)", entry.addr, entry.hash)
+func (s *NetworkVideoContentService) ListNodes(ctx context.Context, req *proto.ListNodesRequest) (*proto.ListNodesResponse, error) {
+	s.mu.RLock()
+	resp := &proto.ListNodesResponse{}
+	for _, addr := range s.members() {
+		resp.Statuses = append(resp.Statuses, &proto.NodeStatus{Address: addr, State: nodeActive})
 	}
-	s.mu.Unlock()
-
-	migrated := 0
-	for _, p := range files {
-		parts := strings.SplitN(p, "/", 2)
-		if len(parts) != 2 {
-			log.Printf("DEBUG: Skipping invalid path: %s", p)
-			continue
-		}
-		vid, fname := parts[0], parts[1]
-		key := vid + "/" + fname
+	for addr, state := range s.draining {
+		resp.Statuses = append(resp.Statuses, &proto.NodeStatus{Address: addr, State: state})
+	}
+	s.mu.RUnlock()
+	sort.Slice(resp.Statuses, func(i, j int) bool { return resp.Statuses[i].Address < resp.Statuses[j].Address })
+	for _, st := range resp.Statuses {
+		resp.Nodes = append(resp.Nodes, st.Address)
+	}
+	if req.GetIncludeStats() {
+		resp.Usage = s.collectStats(ctx, resp.Statuses)
+	}
+	log.Printf("DEBUG: ListNodes returning: %v", resp.Nodes)
+	return resp, nil
+}
 
-		targetAddr, targetClient := s.pickNode(key)
-		if targetClient == nil {
-			log.Printf("DEBUG: No target available for %s", key)
-			continue
-		}
-		log.Printf("DEBUG: Migrating %s from %s to %s", key, addr, targetAddr)
+// AddNode adds a node to the ring and starts a background rebalance that moves
+// every file the new ring assigns to a different node.
+func (s *NetworkVideoContentService) AddNode(ctx context.Context, req *proto.AddNodeRequest) (*proto.AddNodeResponse, error) {
+	addr := req.GetNodeAddress()
+	log.Printf("DEBUG: AddNode called for %s", addr)
+	if leader, err := s.leaderAdmin(ctx); err != nil {
+		return nil, err
+	} else if leader != nil {
+		return leader.AddNode(ctx, req)
+	}
 
-		dataResp, err := client.ReadFile(ctx, &proto.ReadFileRequest{VideoId: vid, Filename: fname})
-		if err != nil {
-			log.Printf("DEBUG: Failed to read %s from %s: %v", key, addr, err)
-			continue
-		}
-		log.Printf("DEBUG: Read %d bytes for %s", len(dataResp.Data), key)
-
-		_, err = targetClient.WriteFile(ctx, &proto.WriteFileRequest{VideoId: vid, Filename: fname, Data: dataResp.Data})
-		if err == nil {
-			client.DeleteFile(ctx, &proto.DeleteFileRequest{VideoId: vid, Filename: fname})
-			migrated++
-			log.Printf("DEBUG: Successfully migrated %s to %s", key, targetAddr)
-		} else {
-			log.Printf("DEBUG: Failed to write %s to %s: %v", key, targetAddr, err)
-		}
+	op, err := s.startRebalance(rebalanceAdd, addr)
+	if err != nil {
+		return nil, err
 	}
+	return &proto.AddNodeResponse{OperationId: op.id}, nil
+}
 
-	s.mu.Lock()
-	if conn, ok := s.conns[addr]; ok {
-		conn.Close()
-		delete(s.conns, addr)
+// RemoveNode drains a node and decommissions it once its files have been
+// copied to their new owners and checked. The node serves reads until then.
+func (s *NetworkVideoContentService) RemoveNode(ctx context.Context, req *proto.RemoveNodeRequest) (*proto.RemoveNodeResponse, error) {
+	addr := req.GetNodeAddress()
+	log.Printf("DEBUG: RemoveNode called for %s", addr)
+	if leader, err := s.leaderAdmin(ctx); err != nil {
+		return nil, err
+	} else if leader != nil {
+		return leader.RemoveNode(ctx, req)
 	}
-	s.mu.Unlock()
 
-	log.Printf("DEBUG: RemoveNode completed, migrated %d files", migrated)
-	return &proto.RemoveNodeResponse{MigratedFileCount: int32(migrated)}, nil
+	op, err := s.startRebalance(rebalanceRemove, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.RemoveNodeResponse{OperationId: op.id}, nil
 }
@@ -0,0 +1,75 @@
+package web
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ShardingKey selects which part of a content path decides the storage node
+// a file is placed on.
+type ShardingKey string
+
+const (
+	// ShardByFile hashes videoId/filename, spreading a video over every node.
+	ShardByFile ShardingKey = "file"
+	// ShardByVideo hashes the videoId, keeping all of a video's files together.
+	ShardByVideo ShardingKey = "video"
+	// ShardBySegment hashes the videoId plus a bucket of consecutive segment
+	// numbers, so long videos spread out in contiguous ranges.
+	ShardBySegment ShardingKey = "segment"
+)
+
+// DefaultSegmentBucketSize is the number of media segments per bucket when
+// sharding by segment range.
+const DefaultSegmentBucketSize = 50
+
+// ParseShardingKey converts a command-line value into a ShardingKey.
+func ParseShardingKey(s string) (ShardingKey, error) {
+	switch k := ShardingKey(s); k {
+	case ShardByFile, ShardByVideo, ShardBySegment:
+		return k, nil
+	}
+	return "", fmt.Errorf("unknown sharding key %q (want file, video or segment)", s)
+}
+
+// placement maps a video file onto the key that is hashed onto the ring.
+type placement struct {
+	key        ShardingKey
+	bucketSize int
+}
+
+func (p placement) keyFor(videoId, filename string) string {
+	switch p.key {
+	case ShardByVideo:
+		return videoId
+	case ShardBySegment:
+		bucket := 0
+		if n, ok := segmentNumber(filename); ok && n > 0 {
+			bucket = (n - 1) / p.bucketSize
+		}
+		return fmt.Sprintf("%s#%d", videoId, bucket)
+	default:
+		return videoId + "/" + filename
+	}
+}
+
+// segmentNumber extracts the segment number from a media segment name such as
+// chunk-0-00042.m4s. The manifest and init segments have no number and land in
+// the first bucket.
+func segmentNumber(filename string) (int, bool) {
+	if !strings.HasPrefix(filename, "chunk-") {
+		return 0, false
+	}
+	stem := strings.TrimSuffix(filename, path.Ext(filename))
+	i := strings.LastIndex(stem, "-")
+	if i < 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(stem[i+1:])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,947 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Kinds of membership change that start a rebalance.
+const (
+	rebalanceAdd    = "add"
+	rebalanceRemove = "remove" // drain, then decommission
+	rebalanceDrain  = "drain"
+)
+
+// Rebalance operation states.
+const (
+	opRunning   = "running"
+	opCompleted = "completed"
+	opFailed    = "failed"
+	opCancelled = "cancelled"
+)
+
+// Rebalance task states.
+const (
+	taskPending = "pending"
+	taskDone    = "done"
+	taskFailed  = "failed"
+)
+
+// rebalanceStatus is the persisted progress of a rebalance operation.
+type rebalanceStatus struct {
+	ID          string
+	Kind        string
+	Node        string
+	State       string
+	Planned     bool
+	TotalFiles  int64
+	TotalBytes  int64
+	MovedFiles  int64
+	MovedBytes  int64
+	FailedFiles int64
+	Error       string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	// MembershipVersion is the membership version the change was committed
+	// as, or 0 if it has not been committed.
+	MembershipVersion int64
+}
+
+func (st rebalanceStatus) toProto() *proto.RebalanceStatus {
+	return &proto.RebalanceStatus{
+		OperationId: st.ID,
+		Kind:        st.Kind,
+		NodeAddress: st.Node,
+		State:       st.State,
+		TotalFiles:  st.TotalFiles,
+		TotalBytes:  st.TotalBytes,
+		MovedFiles:  st.MovedFiles,
+		MovedBytes:  st.MovedBytes,
+		FailedFiles: st.FailedFiles,
+		Error:       st.Error,
+		CreatedAt:   st.CreatedAt.Unix(),
+		UpdatedAt:   st.UpdatedAt.Unix(),
+	}
+}
+
+func (st rebalanceStatus) finished() bool {
+	return st.State != opRunning
+}
+
+// rebalanceTask moves one file from Source to the node that owns it.
+type rebalanceTask struct {
+	Path   string
+	Source string
+	Target string
+	Size   int64
+	State  string
+	Error  string
+}
+
+// rebalanceOp is the in-memory handle of the running rebalance operation.
+type rebalanceOp struct {
+	id     string
+	kind   string
+	node   string
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	status   rebalanceStatus
+	handover bool // stopped because leadership was lost, not cancelled
+}
+
+func (op *rebalanceOp) snapshot() rebalanceStatus {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return op.status
+}
+
+// handOver stops the operation so that the next leader can resume it.
+func (op *rebalanceOp) handOver() {
+	op.mu.Lock()
+	op.handover = true
+	op.mu.Unlock()
+	op.cancel()
+}
+
+func (op *rebalanceOp) handedOver() bool {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return op.handover
+}
+
+func (op *rebalanceOp) update(fn func(st *rebalanceStatus)) rebalanceStatus {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	fn(&op.status)
+	op.status.UpdatedAt = time.Now()
+	return op.status
+}
+
+// recordProgress applies fn to the operation's status and persists the result
+// while still holding op.mu, so that concurrent workers' writes reach the
+// store in the order they were made and a stale count never overwrites a
+// newer one.
+func (s *NetworkVideoContentService) recordProgress(op *rebalanceOp, fn func(st *rebalanceStatus)) (rebalanceStatus, error) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	fn(&op.status)
+	op.status.UpdatedAt = time.Now()
+	return op.status, s.rebalances.updateOp(op.status)
+}
+
+func newOperationID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return "rb-" + hex.EncodeToString(b)
+}
+
+// splitContentPath splits a storage path of the form videoId/filename.
+func splitContentPath(p string) (string, string, bool) {
+	parts := strings.SplitN(p, "/", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// listPageSize is the page size used when streaming file listings from nodes.
+const listPageSize = 1000
+
+// forEachFile calls fn for every file on addr whose path starts with prefix,
+// streaming the listing page by page. Nodes without StreamFiles are listed in
+// one ListFiles call instead. A node that is not connected yet, such as one a
+// dry run plans to add, is listed over a connection that is closed after.
+func (s *NetworkVideoContentService) forEachFile(ctx context.Context, addr, prefix string, fn func(f *proto.FileInfo) error) error {
+	client, release, err := s.tempClient(addr)
+	if err != nil {
+		return err
+	}
+	defer release()
+	req := &proto.ListFilesRequest{Prefix: prefix, PageSize: listPageSize}
+	stream, err := client.StreamFiles(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to list files on %s: %w", addr, err)
+	}
+	for {
+		page, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if status.Code(err) == codes.Unimplemented {
+			req.PageSize = 0
+			page, err = client.ListFiles(ctx, req)
+			if err != nil {
+				return fmt.Errorf("failed to list files on %s: %w", addr, err)
+			}
+			for _, f := range page.Files {
+				if err := fn(f); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list files on %s: %w", addr, err)
+		}
+		for _, f := range page.Files {
+			if err := fn(f); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// throttle limits the average rate of rebalance traffic across all workers.
+type throttle struct {
+	rate int64 // bytes per second, 0 for unlimited
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (t *throttle) wait(ctx context.Context, n int) error {
+	if t.rate <= 0 {
+		return nil
+	}
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	delay := t.next.Sub(now)
+	t.next = t.next.Add(time.Duration(int64(n) * int64(time.Second) / t.rate))
+	t.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startRebalance applies a membership change and starts the rebalance that
+// follows it. Only one rebalance runs at a time.
+func (s *NetworkVideoContentService) startRebalance(kind, addr string) (*rebalanceOp, error) {
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+	if s.activeOp != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "rebalance %s is still running", s.activeOp.id)
+	}
+
+	s.mu.RLock()
+	_, member := s.clients[addr]
+	_, draining := s.draining[addr]
+	s.mu.RUnlock()
+	switch {
+	case kind == rebalanceDrain && !member && !draining:
+		// A node left draining by a failed drain may be drained again.
+		return nil, status.Errorf(codes.FailedPrecondition, "node %s is not an active member", addr)
+	case kind == rebalanceRemove && !member && !draining:
+		return nil, status.Errorf(codes.NotFound, "node %s not found", addr)
+	}
+
+	now := time.Now()
+	st := rebalanceStatus{
+		ID:        newOperationID(),
+		Kind:      kind,
+		Node:      addr,
+		State:     opRunning,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.rebalances.createOp(st); err != nil {
+		return nil, err
+	}
+	version, err := s.applyMembershipChange(kind, addr)
+	if err != nil {
+		st.State = opFailed
+		st.Error = err.Error()
+		s.rebalances.updateOp(st)
+		if errors.Is(err, ErrMembershipConflict) {
+			return nil, status.Error(codes.Aborted, err.Error())
+		}
+		return nil, err
+	}
+	st.MembershipVersion = version
+	s.rebalances.updateOp(st)
+	return s.launchRebalance(st), nil
+}
+
+// applyMembershipChange commits a membership change, updates the ring and
+// keeps the old ring around for fallback reads while files move. It returns
+// the membership version the change was committed as.
+func (s *NetworkVideoContentService) applyMembershipChange(kind, addr string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := s.ringBefore(kind, addr)
+	switch kind {
+	case rebalanceAdd:
+		if err := s.commitMembership(func(nodes map[string]string) { nodes[addr] = nodeActive }); err != nil {
+			return 0, err
+		}
+		if err := s.connectNode(addr); err != nil {
+			return 0, err
+		}
+		delete(s.draining, addr)
+	case rebalanceRemove, rebalanceDrain:
+		if err := s.commitMembership(func(nodes map[string]string) { nodes[addr] = nodeDraining }); err != nil {
+			return 0, err
+		}
+		// The node keeps serving reads until its files have been copied away.
+		if _, err := s.dialNode(addr); err != nil {
+			return 0, err
+		}
+		delete(s.clients, addr)
+		s.draining[addr] = nodeDraining
+	}
+	s.prevRing = before
+	s.rebuildRing()
+	return s.membershipVersion, nil
+}
+
+// ringBefore returns the ring as it was before kind was applied to addr. The
+// caller holds s.mu.
+func (s *NetworkVideoContentService) ringBefore(kind, addr string) hashRing {
+	var before []string
+	for _, m := range s.members() {
+		if m != addr {
+			before = append(before, m)
+		}
+	}
+	if kind != rebalanceAdd {
+		before = append(before, addr)
+	}
+	return newHashRing(before)
+}
+
+// reapplyMembershipChange prepares to resume st, whose membership change was
+// committed by this or a previous leader, without committing it again. It
+// catches up with the stored membership if this frontend has not seen st's
+// version yet and checks that the node still is where st put it.
+func (s *NetworkVideoContentService) reapplyMembershipChange(st rebalanceStatus) error {
+	if st.MembershipVersion == 0 {
+		return fmt.Errorf("rebalance %s stopped before its membership change was committed", st.ID)
+	}
+	s.mu.RLock()
+	version := s.membershipVersion
+	s.mu.RUnlock()
+	if version < st.MembershipVersion {
+		m, err := s.membership.Load()
+		if err != nil {
+			return err
+		}
+		if m == nil || m.Version < st.MembershipVersion {
+			return fmt.Errorf("membership version %d of rebalance %s is not stored", st.MembershipVersion, st.ID)
+		}
+		s.applyRemoteMembership(m)
+	}
+	if err := s.checkResumable(st); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.prevRing == nil {
+		s.prevRing = s.ringBefore(st.Kind, st.Node)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// launchRebalance runs st in the background. The caller must hold opMu.
+func (s *NetworkVideoContentService) launchRebalance(st rebalanceStatus) *rebalanceOp {
+	ctx, cancel := context.WithCancel(context.Background())
+	op := &rebalanceOp{id: st.ID, kind: st.Kind, node: st.Node, cancel: cancel, done: make(chan struct{}), status: st}
+	s.activeOp = op
+	go s.runRebalance(ctx, op)
+	return op
+}
+
+// resumeInterruptedRebalance restarts an operation that was running when the
+// process last exited or, in a shared cluster, when the previous leader
+// stopped.
+func (s *NetworkVideoContentService) resumeInterruptedRebalance() error {
+	ops, err := s.rebalances.listOps()
+	if err != nil {
+		return err
+	}
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+	for _, st := range ops {
+		if st.State != opRunning {
+			continue
+		}
+		if s.activeOp != nil {
+			st.State = opFailed
+			st.Error = "interrupted"
+			s.rebalances.updateOp(st)
+			continue
+		}
+		log.Printf("DEBUG: Resuming interrupted rebalance %s (%s %s)", st.ID, st.Kind, st.Node)
+		if err := s.reapplyMembershipChange(st); err != nil {
+			log.Printf("DEBUG: Failed to resume rebalance %s: %v", st.ID, err)
+			st.State = opFailed
+			st.Error = "could not be resumed: " + err.Error()
+			st.UpdatedAt = time.Now()
+			if err := s.rebalances.updateOp(st); err != nil {
+				return fmt.Errorf("failed to record rebalance %s: %w", st.ID, err)
+			}
+			continue
+		}
+		s.launchRebalance(st)
+	}
+	return nil
+}
+
+func (s *NetworkVideoContentService) runRebalance(ctx context.Context, op *rebalanceOp) {
+	defer close(op.done)
+	st := op.snapshot()
+
+	if !st.Planned {
+		tasks, err := s.planRebalance(ctx, st.Kind, st.Node)
+		if err == nil {
+			err = s.rebalances.addTasks(st.ID, tasks)
+		}
+		if err != nil {
+			s.finishRebalance(op, opFailed, err.Error())
+			return
+		}
+		var bytes int64
+		for _, t := range tasks {
+			bytes += t.Size
+		}
+		st, _ = s.recordProgress(op, func(st *rebalanceStatus) {
+			st.Planned = true
+			st.TotalFiles = int64(len(tasks))
+			st.TotalBytes = bytes
+		})
+		log.Printf("DEBUG: Rebalance %s planned %d files (%d bytes)", st.ID, len(tasks), bytes)
+	}
+
+	tasks, err := s.rebalances.unfinishedTasks(st.ID)
+	if err != nil {
+		s.finishRebalance(op, opFailed, err.Error())
+		return
+	}
+	op.update(func(st *rebalanceStatus) { st.FailedFiles = 0 })
+
+	queue := make(chan rebalanceTask)
+	var wg sync.WaitGroup
+	for i := 0; i < s.rebalanceWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range queue {
+				s.runTask(ctx, op, t)
+			}
+		}()
+	}
+feed:
+	for _, t := range tasks {
+		select {
+		case queue <- t:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	switch {
+	case ctx.Err() != nil:
+		s.finishRebalance(op, opCancelled, "")
+	case op.snapshot().FailedFiles > 0:
+		s.finishRebalance(op, opFailed, "some files could not be moved")
+	case st.Kind != rebalanceAdd:
+		if err := s.completeDrain(ctx, st); err != nil {
+			s.finishRebalance(op, opFailed, err.Error())
+			return
+		}
+		s.finishRebalance(op, opCompleted, "")
+	default:
+		s.finishRebalance(op, opCompleted, "")
+	}
+}
+
+func (s *NetworkVideoContentService) runTask(ctx context.Context, op *rebalanceOp, t rebalanceTask) {
+	// Drains copy files and leave the originals readable until verified.
+	keepSource := op.kind != rebalanceAdd
+	target, n, err := s.moveFile(ctx, t, keepSource)
+	if err != nil {
+		if ctx.Err() != nil {
+			return // left pending for a resume
+		}
+		log.Printf("DEBUG: Rebalance %s failed to move %s from %s: %v", op.id, t.Path, t.Source, err)
+		s.rebalances.setTaskState(op.id, t, target, taskFailed, err.Error())
+		s.recordProgress(op, func(st *rebalanceStatus) { st.FailedFiles++ })
+		return
+	}
+	s.rebalances.setTaskState(op.id, t, target, taskDone, "")
+	s.recordProgress(op, func(st *rebalanceStatus) {
+		st.MovedFiles++
+		st.MovedBytes += n
+	})
+}
+
+// planRebalance lists the files that the current ring places on a different
+// node than the one they are stored on.
+func (s *NetworkVideoContentService) planRebalance(ctx context.Context, kind, node string) ([]rebalanceTask, error) {
+	s.mu.RLock()
+	ring := s.ring
+	sources := s.members()
+	s.mu.RUnlock()
+	if kind != rebalanceAdd {
+		return s.planMoves(ctx, ring, []string{node}, true)
+	}
+	return s.planMoves(ctx, ring, sources, false)
+}
+
+// planMoves lists the files on sources that ring places on another node. If
+// strict is false, sources that cannot be listed are skipped.
+func (s *NetworkVideoContentService) planMoves(ctx context.Context, ring hashRing, sources []string, strict bool) ([]rebalanceTask, error) {
+	var tasks []rebalanceTask
+	for _, src := range sources {
+		err := s.forEachFile(ctx, src, "", func(f *proto.FileInfo) error {
+			vid, fname, ok := splitContentPath(f.Path)
+			if !ok {
+				log.Printf("DEBUG: Skipping invalid path: %s", f.Path)
+				return nil
+			}
+			target := s.ownerIn(ring, vid, fname)
+			if target == "" {
+				return errors.New("no storage nodes available")
+			}
+			if target != src {
+				tasks = append(tasks, rebalanceTask{Path: f.Path, Source: src, Target: target, Size: f.Size, State: taskPending})
+			}
+			return nil
+		})
+		if err != nil {
+			if strict || ctx.Err() != nil {
+				return nil, err
+			}
+			log.Printf("DEBUG: Failed to list files from %s: %v", src, err)
+		}
+	}
+	return tasks, nil
+}
+
+// moveFile has the source node copy a file to its current owner and, unless
+// keepSource is set, deletes it from the source. It returns the node the file
+// was copied to and the number of bytes copied.
+func (s *NetworkVideoContentService) moveFile(ctx context.Context, t rebalanceTask, keepSource bool) (string, int64, error) {
+	vid, fname, _ := splitContentPath(t.Path)
+	target := s.ownerOf(vid, fname)
+	if target == "" {
+		return "", 0, errors.New("no storage nodes available")
+	}
+	if target == t.Source {
+		return target, 0, nil
+	}
+	src, err := s.clientFor(t.Source)
+	if err != nil {
+		return target, 0, err
+	}
+	dst, err := s.clientFor(target)
+	if err != nil {
+		return target, 0, err
+	}
+
+	log.Printf("DEBUG: Migrating %s from %s to %s", t.Path, t.Source, target)
+	if err := s.throttle.wait(ctx, int(t.Size)); err != nil {
+		return target, 0, err
+	}
+	n, err := s.copyFile(ctx, src, dst, t.Source, target, vid, fname)
+	if err != nil {
+		return target, 0, err
+	}
+	if keepSource {
+		return target, n, nil
+	}
+	if _, err := src.DeleteFile(ctx, &proto.DeleteFileRequest{VideoId: vid, Filename: fname}); err != nil {
+		log.Printf("DEBUG: Failed to delete %s from %s after copy: %v", t.Path, t.Source, err)
+	}
+	return target, n, nil
+}
+
+// copyFile asks the source node to send a file straight to the target and
+// checks the target's checksum against the one the source sent. Sources that
+// predate CopyTo have the file relayed through this server instead.
+func (s *NetworkVideoContentService) copyFile(ctx context.Context, src, dst proto.VideoStorageServiceClient, source, target, vid, fname string) (int64, error) {
+	resp, err := src.CopyTo(ctx, &proto.CopyToRequest{VideoId: vid, Filename: fname, Target: target})
+	if status.Code(err) == codes.Unimplemented {
+		return relayFile(ctx, src, dst, source, target, vid, fname)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("copy from %s: %w", source, err)
+	}
+	stat, err := dst.StatFile(ctx, &proto.StatFileRequest{VideoId: vid, Filename: fname})
+	if err != nil {
+		return 0, fmt.Errorf("stat on %s: %w", target, err)
+	}
+	if stat.Sha256 != resp.Sha256 {
+		return 0, fmt.Errorf("checksum mismatch for %s/%s on %s", vid, fname, target)
+	}
+	return resp.Size, nil
+}
+
+// relayFile copies a file by reading it from the source and writing it to the
+// target through this server, then checks the target's checksum against the
+// data it sent.
+func relayFile(ctx context.Context, src, dst proto.VideoStorageServiceClient, source, target, vid, fname string) (int64, error) {
+	dataResp, err := src.ReadFile(ctx, &proto.ReadFileRequest{VideoId: vid, Filename: fname})
+	if err != nil {
+		return 0, fmt.Errorf("read from %s: %w", source, err)
+	}
+	if _, err := dst.WriteFile(ctx, &proto.WriteFileRequest{VideoId: vid, Filename: fname, Data: dataResp.Data}); err != nil {
+		return 0, fmt.Errorf("write to %s: %w", target, err)
+	}
+	stat, err := dst.StatFile(ctx, &proto.StatFileRequest{VideoId: vid, Filename: fname})
+	if status.Code(err) == codes.Unimplemented {
+		return int64(len(dataResp.Data)), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("stat on %s: %w", target, err)
+	}
+	sum := sha256.Sum256(dataResp.Data)
+	if stat.Sha256 != hex.EncodeToString(sum[:]) {
+		return 0, fmt.Errorf("checksum mismatch for %s/%s on %s", vid, fname, target)
+	}
+	return int64(len(dataResp.Data)), nil
+}
+
+func (s *NetworkVideoContentService) finishRebalance(op *rebalanceOp, state, msg string) {
+	if state == opCancelled && op.handedOver() {
+		// Left running in the store for the next leader to resume.
+		s.opMu.Lock()
+		if s.activeOp == op {
+			s.activeOp = nil
+		}
+		s.opMu.Unlock()
+		log.Printf("DEBUG: Rebalance %s stopped for the next leader", op.id)
+		return
+	}
+	st, err := s.recordProgress(op, func(st *rebalanceStatus) {
+		st.State = state
+		st.Error = msg
+	})
+	if err != nil {
+		log.Printf("DEBUG: Failed to record rebalance %s: %v", st.ID, err)
+	}
+
+	s.opMu.Lock()
+	if s.activeOp == op {
+		s.activeOp = nil
+	}
+	s.opMu.Unlock()
+
+	if state == opCompleted {
+		s.mu.Lock()
+		s.prevRing = nil
+		version := s.membershipVersion
+		s.mu.Unlock()
+		if shared, ok := s.membership.(*etcdMembershipStore); ok {
+			// Tell the other frontends they can stop reading from the old ring.
+			if err := shared.Settle(version); err != nil {
+				log.Printf("DEBUG: %v", err)
+			}
+		}
+	}
+	log.Printf("DEBUG: Rebalance %s %s: moved %d/%d files (%d bytes), %d failed",
+		st.ID, state, st.MovedFiles, st.TotalFiles, st.MovedBytes, st.FailedFiles)
+}
+
+// rebalanceStatusFor returns the latest status of operation id, preferring the
+// live copy of the running operation.
+func (s *NetworkVideoContentService) rebalanceStatusFor(id string) (rebalanceStatus, error) {
+	s.opMu.Lock()
+	op := s.activeOp
+	s.opMu.Unlock()
+	if op != nil && op.id == id {
+		return op.snapshot(), nil
+	}
+	st, err := s.rebalances.getOp(id)
+	if err != nil {
+		return rebalanceStatus{}, err
+	}
+	if st == nil {
+		return rebalanceStatus{}, status.Errorf(codes.NotFound, "rebalance %s not found", id)
+	}
+	return *st, nil
+}
+
+func (s *NetworkVideoContentService) ListRebalances(ctx context.Context, req *proto.ListRebalancesRequest) (*proto.ListRebalancesResponse, error) {
+	if leader, err := s.leaderAdmin(ctx); err != nil {
+		return nil, err
+	} else if leader != nil {
+		return leader.ListRebalances(ctx, req)
+	}
+	ops, err := s.rebalances.listOps()
+	if err != nil {
+		return nil, err
+	}
+	s.opMu.Lock()
+	active := s.activeOp
+	s.opMu.Unlock()
+
+	resp := &proto.ListRebalancesResponse{}
+	for _, st := range ops {
+		if active != nil && active.id == st.ID {
+			st = active.snapshot()
+		}
+		resp.Operations = append(resp.Operations, st.toProto())
+	}
+	return resp, nil
+}
+
+func (s *NetworkVideoContentService) GetRebalance(ctx context.Context, req *proto.GetRebalanceRequest) (*proto.RebalanceStatus, error) {
+	if leader, err := s.leaderAdmin(ctx); err != nil {
+		return nil, err
+	} else if leader != nil {
+		return leader.GetRebalance(ctx, req)
+	}
+	st, err := s.rebalanceStatusFor(req.GetOperationId())
+	if err != nil {
+		return nil, err
+	}
+	return st.toProto(), nil
+}
+
+// WatchRebalance streams the progress of an operation until it finishes.
+func (s *NetworkVideoContentService) WatchRebalance(req *proto.GetRebalanceRequest, stream proto.VideoContentAdminService_WatchRebalanceServer) error {
+	if leader, err := s.leaderAdmin(stream.Context()); err != nil {
+		return err
+	} else if leader != nil {
+		return relayWatch(leader, req, stream)
+	}
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	var last rebalanceStatus
+	for {
+		st, err := s.rebalanceStatusFor(req.GetOperationId())
+		if err != nil {
+			return err
+		}
+		if st != last {
+			if err := stream.Send(st.toProto()); err != nil {
+				return err
+			}
+			last = st
+		}
+		if st.finished() {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// relayWatch streams a leader's WatchRebalance to a client of this frontend.
+func relayWatch(leader proto.VideoContentAdminServiceClient, req *proto.GetRebalanceRequest, stream proto.VideoContentAdminService_WatchRebalanceServer) error {
+	in, err := leader.WatchRebalance(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	for {
+		st, err := in.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(st); err != nil {
+			return err
+		}
+	}
+}
+
+// CancelRebalance stops the running operation. Files that have not moved yet
+// stay where they are until the operation is resumed.
+func (s *NetworkVideoContentService) CancelRebalance(ctx context.Context, req *proto.GetRebalanceRequest) (*proto.RebalanceStatus, error) {
+	if leader, err := s.leaderAdmin(ctx); err != nil {
+		return nil, err
+	} else if leader != nil {
+		return leader.CancelRebalance(ctx, req)
+	}
+	s.opMu.Lock()
+	op := s.activeOp
+	s.opMu.Unlock()
+	if op == nil || op.id != req.GetOperationId() {
+		st, err := s.rebalanceStatusFor(req.GetOperationId())
+		if err != nil {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.FailedPrecondition, "rebalance %s is %s", st.ID, st.State)
+	}
+
+	log.Printf("DEBUG: Cancelling rebalance %s", op.id)
+	op.cancel()
+	select {
+	case <-op.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return op.snapshot().toProto(), nil
+}
+
+// ResumeRebalance restarts a cancelled or failed operation, retrying every
+// file that has not moved yet.
+func (s *NetworkVideoContentService) ResumeRebalance(ctx context.Context, req *proto.GetRebalanceRequest) (*proto.RebalanceStatus, error) {
+	if leader, err := s.leaderAdmin(ctx); err != nil {
+		return nil, err
+	} else if leader != nil {
+		return leader.ResumeRebalance(ctx, req)
+	}
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+	if s.activeOp != nil {
+		if s.activeOp.id == req.GetOperationId() {
+			return s.activeOp.snapshot().toProto(), nil
+		}
+		return nil, status.Errorf(codes.FailedPrecondition, "rebalance %s is still running", s.activeOp.id)
+	}
+
+	st, err := s.rebalances.getOp(req.GetOperationId())
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, status.Errorf(codes.NotFound, "rebalance %s not found", req.GetOperationId())
+	}
+	if st.State == opCompleted {
+		return st.toProto(), nil
+	}
+	if err := s.checkResumable(*st); err != nil {
+		return nil, err
+	}
+
+	log.Printf("DEBUG: Resuming rebalance %s", st.ID)
+	st.State = opRunning
+	st.Error = ""
+	st.UpdatedAt = time.Now()
+	if err := s.rebalances.updateOp(*st); err != nil {
+		return nil, err
+	}
+	return s.launchRebalance(*st).snapshot().toProto(), nil
+}
+
+// checkResumable reports whether the node's membership still reflects st's
+// change. It may not, if the change was never applied because it failed, or
+// if the node was undrained or added back since; the operation's moves would
+// then work against the current ring.
+func (s *NetworkVideoContentService) checkResumable(st rebalanceStatus) error {
+	s.mu.RLock()
+	_, member := s.clients[st.Node]
+	state := s.draining[st.Node]
+	s.mu.RUnlock()
+	switch st.Kind {
+	case rebalanceAdd:
+		if !member {
+			return status.Errorf(codes.FailedPrecondition, "node %s is no longer an active member; start a new add instead of resuming %s", st.Node, st.ID)
+		}
+	case rebalanceRemove, rebalanceDrain:
+		if state != nodeDraining {
+			return status.Errorf(codes.FailedPrecondition, "node %s is not draining; start a new %s instead of resuming %s", st.Node, st.Kind, st.ID)
+		}
+	}
+	return nil
+}
+
+// PlanMembershipChange reports which files a membership change would move
+// without applying it.
+func (s *NetworkVideoContentService) PlanMembershipChange(ctx context.Context, req *proto.PlanMembershipChangeRequest) (*proto.PlanMembershipChangeResponse, error) {
+	addr := req.GetNodeAddress()
+	if leader, err := s.leaderAdmin(ctx); err != nil {
+		return nil, err
+	} else if leader != nil {
+		return leader.PlanMembershipChange(ctx, req)
+	}
+
+	s.mu.RLock()
+	current := s.members()
+	_, member := s.clients[addr]
+	_, draining := s.draining[addr]
+	s.mu.RUnlock()
+
+	var after, sources []string
+	switch req.GetAction() {
+	case rebalanceAdd:
+		after = append(slices.Clone(current), addr)
+		sources = after
+		if member {
+			after, sources = current, current
+		}
+	case rebalanceRemove, rebalanceDrain:
+		if !member && !draining {
+			return nil, status.Errorf(codes.NotFound, "node %s not found", addr)
+		}
+		for _, m := range current {
+			if m != addr {
+				after = append(after, m)
+			}
+		}
+		sources = []string{addr}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown action %q (want add, remove or drain)", req.GetAction())
+	}
+	sort.Strings(after)
+
+	tasks, err := s.planMoves(ctx, newHashRing(after), sources, req.GetAction() != rebalanceAdd)
+	if err != nil {
+		return nil, err
+	}
+
+	type route struct{ source, target string }
+	byRoute := make(map[route]*proto.PlannedTransfer)
+	resp := &proto.PlanMembershipChangeResponse{Nodes: after}
+	for _, t := range tasks {
+		r := route{t.Source, t.Target}
+		pt, ok := byRoute[r]
+		if !ok {
+			pt = &proto.PlannedTransfer{Source: t.Source, Target: t.Target}
+			byRoute[r] = pt
+			resp.Transfers = append(resp.Transfers, pt)
+		}
+		pt.FileCount++
+		pt.Bytes += t.Size
+		resp.TotalFiles++
+		resp.TotalBytes += t.Size
+	}
+	sort.Slice(resp.Transfers, func(i, j int) bool {
+		a, b := resp.Transfers[i], resp.Transfers[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Target < b.Target
+	})
+	log.Printf("DEBUG: Plan to %s %s moves %d files (%d bytes)", req.GetAction(), addr, resp.TotalFiles, resp.TotalBytes)
+	return resp, nil
+}
@@ -0,0 +1,201 @@
+package web
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// rebalanceStore persists rebalance operations and their per-file tasks so an
+// interrupted operation can be resumed.
+type rebalanceStore interface {
+	createOp(op rebalanceStatus) error
+	updateOp(op rebalanceStatus) error
+	// getOp returns the operation with the given id, or nil if there is none.
+	getOp(id string) (*rebalanceStatus, error)
+	// listOps returns every operation, newest first.
+	listOps() ([]rebalanceStatus, error)
+	addTasks(opID string, tasks []rebalanceTask) error
+	// unfinishedTasks returns the tasks of an operation that are pending or
+	// failed, ordered by path.
+	unfinishedTasks(opID string) ([]rebalanceTask, error)
+	setTaskState(opID string, t rebalanceTask, target, state, msg string) error
+}
+
+// sqliteRebalanceStore keeps rebalances in the state database.
+type sqliteRebalanceStore struct {
+	db *sql.DB
+}
+
+var _ rebalanceStore = (*sqliteRebalanceStore)(nil)
+
+// openStateDB opens the SQLite database that holds the cluster state of a
+// NetworkVideoContentService.
+func openStateDB(dbPath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state database: %w", err)
+	}
+	// A single connection serialises writers and keeps :memory: databases alive.
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+func newSQLiteRebalanceStore(db *sql.DB) (*sqliteRebalanceStore, error) {
+	createTablesQuery := `
+	CREATE TABLE IF NOT EXISTS rebalance_ops (
+		id TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		node TEXT NOT NULL,
+		state TEXT NOT NULL,
+		planned INTEGER NOT NULL DEFAULT 0,
+		total_files INTEGER NOT NULL DEFAULT 0,
+		total_bytes INTEGER NOT NULL DEFAULT 0,
+		moved_files INTEGER NOT NULL DEFAULT 0,
+		moved_bytes INTEGER NOT NULL DEFAULT 0,
+		failed_files INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL,
+		membership_version INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS rebalance_tasks (
+		op_id TEXT NOT NULL,
+		path TEXT NOT NULL,
+		source TEXT NOT NULL,
+		target TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		state TEXT NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (op_id, path, source)
+	);`
+	if _, err := db.Exec(createTablesQuery); err != nil {
+		return nil, fmt.Errorf("failed to create rebalance tables: %w", err)
+	}
+	// Databases created before resumes kept the membership version lack it.
+	_, err := db.Exec("ALTER TABLE rebalance_ops ADD COLUMN membership_version INTEGER NOT NULL DEFAULT 0")
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("failed to upgrade rebalance tables: %w", err)
+	}
+	return &sqliteRebalanceStore{db: db}, nil
+}
+
+func (st *sqliteRebalanceStore) createOp(op rebalanceStatus) error {
+	_, err := st.db.Exec(`INSERT INTO rebalance_ops
+		(id, kind, node, state, created_at, updated_at, membership_version) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		op.ID, op.Kind, op.Node, op.State, op.CreatedAt.UnixNano(), op.UpdatedAt.UnixNano(), op.MembershipVersion)
+	if err != nil {
+		return fmt.Errorf("failed to insert rebalance: %w", err)
+	}
+	return nil
+}
+
+func (st *sqliteRebalanceStore) updateOp(op rebalanceStatus) error {
+	_, err := st.db.Exec(`UPDATE rebalance_ops SET
+		state = ?, planned = ?, total_files = ?, total_bytes = ?, moved_files = ?,
+		moved_bytes = ?, failed_files = ?, error = ?, updated_at = ?, membership_version = ?
+		WHERE id = ?`,
+		op.State, op.Planned, op.TotalFiles, op.TotalBytes, op.MovedFiles,
+		op.MovedBytes, op.FailedFiles, op.Error, op.UpdatedAt.UnixNano(), op.MembershipVersion, op.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update rebalance: %w", err)
+	}
+	return nil
+}
+
+const rebalanceOpColumns = `id, kind, node, state, planned, total_files, total_bytes,
+	moved_files, moved_bytes, failed_files, error, created_at, updated_at, membership_version`
+
+func scanRebalanceOp(row interface{ Scan(...any) error }) (rebalanceStatus, error) {
+	var op rebalanceStatus
+	var created, updated int64
+	err := row.Scan(&op.ID, &op.Kind, &op.Node, &op.State, &op.Planned, &op.TotalFiles, &op.TotalBytes,
+		&op.MovedFiles, &op.MovedBytes, &op.FailedFiles, &op.Error, &created, &updated, &op.MembershipVersion)
+	op.CreatedAt = time.Unix(0, created)
+	op.UpdatedAt = time.Unix(0, updated)
+	return op, err
+}
+
+func (st *sqliteRebalanceStore) getOp(id string) (*rebalanceStatus, error) {
+	op, err := scanRebalanceOp(st.db.QueryRow("SELECT "+rebalanceOpColumns+" FROM rebalance_ops WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rebalance: %w", err)
+	}
+	return &op, nil
+}
+
+func (st *sqliteRebalanceStore) listOps() ([]rebalanceStatus, error) {
+	rows, err := st.db.Query("SELECT " + rebalanceOpColumns + " FROM rebalance_ops ORDER BY created_at DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rebalances: %w", err)
+	}
+	defer rows.Close()
+
+	var results []rebalanceStatus
+	for rows.Next() {
+		op, err := scanRebalanceOp(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		results = append(results, op)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return results, nil
+}
+
+func (st *sqliteRebalanceStore) addTasks(opID string, tasks []rebalanceTask) error {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO rebalance_tasks
+		(op_id, path, source, target, size, state) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare task insert: %w", err)
+	}
+	defer stmt.Close()
+	for _, t := range tasks {
+		if _, err := stmt.Exec(opID, t.Path, t.Source, t.Target, t.Size, t.State); err != nil {
+			return fmt.Errorf("failed to insert rebalance task: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (st *sqliteRebalanceStore) unfinishedTasks(opID string) ([]rebalanceTask, error) {
+	rows, err := st.db.Query(`SELECT path, source, target, size, state, error FROM rebalance_tasks
+		WHERE op_id = ? AND state != ? ORDER BY path`, opID, taskDone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rebalance tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var results []rebalanceTask
+	for rows.Next() {
+		var t rebalanceTask
+		if err := rows.Scan(&t.Path, &t.Source, &t.Target, &t.Size, &t.State, &t.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		results = append(results, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return results, nil
+}
+
+func (st *sqliteRebalanceStore) setTaskState(opID string, t rebalanceTask, target, state, msg string) error {
+	_, err := st.db.Exec(`UPDATE rebalance_tasks SET target = ?, state = ?, error = ?
+		WHERE op_id = ? AND path = ? AND source = ?`, target, state, msg, opID, t.Path, t.Source)
+	if err != nil {
+		return fmt.Errorf("failed to update rebalance task: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestService(t *testing.T, nodes ...string) *NetworkVideoContentService {
+	t.Helper()
+	s, err := NewNetworkVideoContentService(freeAddr(t), nodes)
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	return s
+}
+
+// storeFailedOp records a failed operation for kind and node, as a rebalance
+// that stopped before finishing leaves behind.
+func storeFailedOp(t *testing.T, s *NetworkVideoContentService, kind, node string) string {
+	t.Helper()
+	now := time.Now()
+	st := rebalanceStatus{
+		ID:        newOperationID(),
+		Kind:      kind,
+		Node:      node,
+		State:     opFailed,
+		Error:     "interrupted",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.rebalances.createOp(st); err != nil {
+		t.Fatalf("createOp: %v", err)
+	}
+	return st.ID
+}
+
+// storeRunningOp records a running operation whose membership change was
+// committed as version, as a leader that stopped mid-rebalance leaves behind.
+func storeRunningOp(t *testing.T, s *NetworkVideoContentService, kind, node string, version int64) string {
+	t.Helper()
+	now := time.Now()
+	st := rebalanceStatus{
+		ID:                newOperationID(),
+		Kind:              kind,
+		Node:              node,
+		State:             opRunning,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		MembershipVersion: version,
+	}
+	if err := s.rebalances.createOp(st); err != nil {
+		t.Fatalf("createOp: %v", err)
+	}
+	return st.ID
+}
+
+func TestResumeRebalanceRefusesStaleMembership(t *testing.T) {
+	seed, node, other := startStorageNode(t), startStorageNode(t), startStorageNode(t)
+	s := newTestService(t, seed, node)
+
+	tests := []struct {
+		name string
+		kind string
+		node string
+	}{
+		// A drain whose membership change failed, or that was undrained since.
+		{"drain of active node", rebalanceDrain, node},
+		{"remove of active node", rebalanceRemove, node},
+		// An add whose node never joined.
+		{"add of non-member", rebalanceAdd, other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := storeFailedOp(t, s, tt.kind, tt.node)
+			_, err := s.ResumeRebalance(context.Background(), &proto.GetRebalanceRequest{OperationId: id})
+			if status.Code(err) != codes.FailedPrecondition {
+				t.Fatalf("ResumeRebalance = %v, want FailedPrecondition", err)
+			}
+		})
+	}
+	waitIdle(t, s)
+	for _, addr := range []string{seed, node} {
+		if got := nodeState(t, s, addr); got != nodeActive {
+			t.Errorf("node %s state = %q, want %q", addr, got, nodeActive)
+		}
+	}
+	if got := nodeState(t, s, other); got != "" {
+		t.Errorf("node %s state = %q, want it not to be a member", other, got)
+	}
+}
+
+func TestResumeRebalanceFinishesDrain(t *testing.T) {
+	seed, node := startStorageNode(t), startStorageNode(t)
+	s := newTestService(t, seed, node)
+
+	// The drain took the node out of the ring and then stopped.
+	if _, err := s.applyMembershipChange(rebalanceDrain, node); err != nil {
+		t.Fatalf("applyMembershipChange: %v", err)
+	}
+	id := storeFailedOp(t, s, rebalanceDrain, node)
+
+	if _, err := s.ResumeRebalance(context.Background(), &proto.GetRebalanceRequest{OperationId: id}); err != nil {
+		t.Fatalf("ResumeRebalance: %v", err)
+	}
+	waitIdle(t, s)
+	if got := nodeState(t, s, node); got != nodeDrained {
+		t.Errorf("node state = %q, want %q", got, nodeDrained)
+	}
+}
+
+func TestPlanMembershipChangeLeavesRingAlone(t *testing.T) {
+	seed, other, added := startStorageNode(t), startStorageNode(t), startStorageNode(t)
+	s := newTestService(t, seed, other)
+	for i := 0; i < 20; i++ {
+		if err := s.Write("video", fmt.Sprintf("chunk-stream0-%05d.m4s", i), []byte("segment")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	s.mu.RLock()
+	before := s.members()
+	s.mu.RUnlock()
+	resp, err := s.PlanMembershipChange(context.Background(), &proto.PlanMembershipChangeRequest{Action: rebalanceAdd, NodeAddress: added})
+	if err != nil {
+		t.Fatalf("PlanMembershipChange: %v", err)
+	}
+	if !slices.Contains(resp.Nodes, added) || len(resp.Nodes) != 3 {
+		t.Errorf("planned nodes = %v, want %v plus %s", resp.Nodes, before, added)
+	}
+	for _, tr := range resp.Transfers {
+		if tr.Target != added {
+			t.Errorf("planned transfer %s -> %s, want every file moving to %s", tr.Source, tr.Target, added)
+		}
+	}
+
+	s.mu.RLock()
+	after := s.members()
+	s.mu.RUnlock()
+	if !slices.Equal(before, after) {
+		t.Errorf("members changed from %v to %v by a dry run", before, after)
+	}
+	if got := nodeState(t, s, added); got != "" {
+		t.Errorf("planned node state = %q, want it not to be a member", got)
+	}
+}
+
+func TestRebalanceProgressIsStoredInOrder(t *testing.T) {
+	s := newTestService(t, startStorageNode(t))
+	id := storeFailedOp(t, s, rebalanceAdd, "node")
+	st, err := s.rebalances.getOp(id)
+	if err != nil {
+		t.Fatalf("getOp: %v", err)
+	}
+	op := &rebalanceOp{id: id, status: *st}
+
+	const workers, files = 8, 50
+	done := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < files; j++ {
+				if _, err := s.recordProgress(op, func(st *rebalanceStatus) { st.MovedFiles++ }); err != nil {
+					t.Errorf("recordProgress: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < workers; i++ {
+		<-done
+	}
+	st, err = s.rebalances.getOp(id)
+	if err != nil {
+		t.Fatalf("getOp: %v", err)
+	}
+	if st.MovedFiles != workers*files {
+		t.Errorf("stored MovedFiles = %d, want %d", st.MovedFiles, workers*files)
+	}
+}
+
+func TestResumeInterruptedRebalanceKeepsMembership(t *testing.T) {
+	seed, node := startStorageNode(t), startStorageNode(t)
+	s := newTestService(t, seed)
+	version, err := s.applyMembershipChange(rebalanceAdd, node)
+	if err != nil {
+		t.Fatalf("applyMembershipChange: %v", err)
+	}
+	id := storeRunningOp(t, s, rebalanceAdd, node, version)
+
+	if err := s.resumeInterruptedRebalance(); err != nil {
+		t.Fatalf("resumeInterruptedRebalance: %v", err)
+	}
+	waitIdle(t, s)
+	st, err := s.rebalances.getOp(id)
+	if err != nil {
+		t.Fatalf("getOp: %v", err)
+	}
+	if st.State != opCompleted {
+		t.Errorf("resumed rebalance state = %q (%s), want %q", st.State, st.Error, opCompleted)
+	}
+	s.mu.RLock()
+	got := s.membershipVersion
+	s.mu.RUnlock()
+	if got != version {
+		t.Errorf("membership version = %d after resuming, want %d committed by the interrupted add", got, version)
+	}
+}
+
+func TestResumeInterruptedRebalanceFailsStaleOp(t *testing.T) {
+	seed, node := startStorageNode(t), startStorageNode(t)
+	s := newTestService(t, seed, node)
+	s.mu.RLock()
+	version := s.membershipVersion
+	s.mu.RUnlock()
+
+	tests := []struct {
+		name    string
+		version int64
+	}{
+		// The node was undrained after the drain was committed.
+		{"membership moved on", version},
+		// The leader stopped before committing the drain.
+		{"never committed", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := storeRunningOp(t, s, rebalanceDrain, node, tt.version)
+			if err := s.resumeInterruptedRebalance(); err != nil {
+				t.Fatalf("resumeInterruptedRebalance: %v", err)
+			}
+			waitIdle(t, s)
+			st, err := s.rebalances.getOp(id)
+			if err != nil {
+				t.Fatalf("getOp: %v", err)
+			}
+			if st.State != opFailed {
+				t.Errorf("stale rebalance state = %q, want %q", st.State, opFailed)
+			}
+			if got := nodeState(t, s, node); got != nodeActive {
+				t.Errorf("node state = %q, want %q", got, nodeActive)
+			}
+		})
+	}
+}
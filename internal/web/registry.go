@@ -0,0 +1,219 @@
+package web
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// registryRetryInterval is how often membership changes that could not start,
+// for example because another rebalance was running, are retried.
+const registryRetryInterval = 2 * time.Second
+
+// nodeRegistry tracks the storage nodes that have registered themselves in
+// etcd under a key prefix, each key kept alive by the node's lease.
+type nodeRegistry struct {
+	cli    *clientv3.Client
+	prefix string
+
+	mu         sync.Mutex
+	registered map[string]bool
+	expired    map[string]bool // nodes whose lease ran out and still need a drain
+	// decommissioned holds nodes removed from the cluster whose registration
+	// may still be live. They are not added back until they register again.
+	decommissioned map[string]bool
+}
+
+// WithNodeRegistry makes the service watch prefix in etcd for self-registered
+// storage nodes. Nodes are added when they register and drained when their
+// lease expires.
+func WithNodeRegistry(endpoints []string, prefix string) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.registryEndpoints = endpoints
+		s.registryPrefix = prefix
+	}
+}
+
+func newNodeRegistry(endpoints []string, prefix string) (*nodeRegistry, error) {
+	cli, err := clientv3.New(clientv3.Config{Endpoints: endpoints, DialTimeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &nodeRegistry{
+		cli:            cli,
+		prefix:         prefix,
+		registered:     make(map[string]bool),
+		expired:        make(map[string]bool),
+		decommissioned: make(map[string]bool),
+	}, nil
+}
+
+// watchRegistry follows registrations until ctx is cancelled, starting a
+// rebalance for each node that joins or whose lease expires. When the watch
+// ends, for example because its revision was compacted, the registrations are
+// listed again and watched from the new revision.
+func (s *NetworkVideoContentService) watchRegistry(ctx context.Context, r *nodeRegistry) {
+	for ctx.Err() == nil {
+		rev, err := r.list(ctx)
+		if err != nil {
+			log.Printf("DEBUG: Failed to list registered nodes: %v", err)
+		} else {
+			s.reconcileRegistry(r)
+			s.followRegistry(ctx, r, rev)
+		}
+		select {
+		case <-time.After(registryRetryInterval):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// list replaces the known registrations with those in etcd and returns the
+// revision they were read at. Nodes that were registered before but are no
+// longer listed lost their lease while nobody was watching.
+func (r *nodeRegistry) list(ctx context.Context) (int64, error) {
+	resp, err := r.cli.Get(ctx, r.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return 0, err
+	}
+	registered := make(map[string]bool)
+	for _, kv := range resp.Kvs {
+		registered[strings.TrimPrefix(string(kv.Key), r.prefix)] = true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for addr := range r.registered {
+		if !registered[addr] {
+			log.Printf("DEBUG: Storage node %s registration expired", addr)
+			r.expired[addr] = true
+		}
+	}
+	for addr := range registered {
+		delete(r.expired, addr)
+	}
+	r.registered = registered
+	return resp.Header.Revision, nil
+}
+
+// followRegistry applies registration changes after rev until the watch ends.
+func (s *NetworkVideoContentService) followRegistry(ctx context.Context, r *nodeRegistry, rev int64) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ticker := time.NewTicker(registryRetryInterval)
+	defer ticker.Stop()
+	wch := r.cli.Watch(ctx, r.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+	for {
+		select {
+		case wr, ok := <-wch:
+			if !ok {
+				return
+			}
+			if err := wr.Err(); err != nil {
+				log.Printf("DEBUG: Registry watch error: %v", err)
+				return
+			}
+			r.mu.Lock()
+			for _, ev := range wr.Events {
+				addr := strings.TrimPrefix(string(ev.Kv.Key), r.prefix)
+				switch ev.Type {
+				case clientv3.EventTypePut:
+					if ev.IsCreate() {
+						log.Printf("DEBUG: Storage node %s registered", addr)
+						// A new registration is the node asking to join again.
+						delete(r.decommissioned, addr)
+					}
+					r.registered[addr] = true
+					delete(r.expired, addr)
+				case clientv3.EventTypeDelete:
+					log.Printf("DEBUG: Storage node %s registration expired", addr)
+					delete(r.registered, addr)
+					r.expired[addr] = true
+				}
+			}
+			r.mu.Unlock()
+			s.reconcileRegistry(r)
+		case <-ticker.C:
+			s.reconcileRegistry(r)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// forget stops the registry from adding addr back after it was decommissioned
+// and removes its registration, so it only rejoins if it registers again.
+func (r *nodeRegistry) forget(addr string) {
+	r.mu.Lock()
+	r.decommissioned[addr] = true
+	delete(r.registered, addr)
+	delete(r.expired, addr)
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := r.cli.Delete(ctx, r.prefix+addr); err != nil {
+		log.Printf("DEBUG: Failed to remove registration of decommissioned node %s: %v", addr, err)
+	}
+}
+
+// reconcileRegistry starts the next membership change the registry calls for.
+// Only one rebalance runs at a time, so the rest wait for a later call, and
+// only the leader of a shared cluster starts them.
+func (s *NetworkVideoContentService) reconcileRegistry(r *nodeRegistry) {
+	if !s.isLeader() {
+		return
+	}
+	s.opMu.Lock()
+	busy := s.activeOp != nil
+	s.opMu.Unlock()
+	if busy {
+		return
+	}
+
+	r.mu.Lock()
+	var joined, gone []string
+	s.mu.RLock()
+	for addr := range r.registered {
+		_, member := s.clients[addr]
+		_, draining := s.draining[addr]
+		if !member && !draining && !r.decommissioned[addr] {
+			joined = append(joined, addr)
+		}
+	}
+	for addr := range r.expired {
+		if _, member := s.clients[addr]; member {
+			gone = append(gone, addr)
+		} else {
+			delete(r.expired, addr)
+		}
+	}
+	s.mu.RUnlock()
+	r.mu.Unlock()
+	sort.Strings(joined)
+	sort.Strings(gone)
+
+	switch {
+	case len(joined) > 0:
+		op, err := s.startRebalance(rebalanceAdd, joined[0])
+		if err != nil {
+			log.Printf("DEBUG: Failed to add registered node %s: %v", joined[0], err)
+			return
+		}
+		log.Printf("DEBUG: Adding registered node %s (rebalance %s)", joined[0], op.id)
+	case len(gone) > 0:
+		op, err := s.startRebalance(rebalanceDrain, gone[0])
+		if err != nil {
+			log.Printf("DEBUG: Failed to drain expired node %s: %v", gone[0], err)
+			return
+		}
+		r.mu.Lock()
+		delete(r.expired, gone[0])
+		r.mu.Unlock()
+		log.Printf("DEBUG: Draining expired node %s (rebalance %s)", gone[0], op.id)
+	}
+}
@@ -0,0 +1,230 @@
+package web
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"tritontube/internal/proto"
+	"tritontube/internal/storage"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	"google.golang.org/grpc"
+)
+
+const testRegistryPrefix = "/test/storage/"
+
+// startEtcd runs a single-member etcd server for the test and returns its
+// client endpoint.
+func startEtcd(t *testing.T) string {
+	t.Helper()
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	clientURL, _ := url.Parse("http://" + freeAddr(t))
+	peerURL, _ := url.Parse("http://" + freeAddr(t))
+	cfg.ListenClientUrls = []url.URL{*clientURL}
+	cfg.AdvertiseClientUrls = []url.URL{*clientURL}
+	cfg.ListenPeerUrls = []url.URL{*peerURL}
+	cfg.AdvertisePeerUrls = []url.URL{*peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatalf("failed to start etcd: %v", err)
+	}
+	t.Cleanup(e.Close)
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(30 * time.Second):
+		t.Fatal("etcd did not become ready")
+	}
+	return clientURL.Host
+}
+
+func newEtcdClient(t *testing.T, endpoint string) *clientv3.Client {
+	t.Helper()
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{endpoint}, DialTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("failed to connect to etcd: %v", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	return cli
+}
+
+// freeAddr returns a loopback address with a port nothing is listening on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	return lis.Addr().String()
+}
+
+// startStorageNode serves a storage node from a temporary directory and
+// returns its address.
+func startStorageNode(t *testing.T) string {
+	t.Helper()
+	return startStorageNodeAt(t, "127.0.0.1:0")
+}
+
+// startStorageNodeAt is startStorageNode listening on addr.
+func startStorageNodeAt(t *testing.T, addr string) string {
+	t.Helper()
+	srv, err := storage.NewStorageServer(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create storage server: %v", err)
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := grpc.NewServer()
+	proto.RegisterVideoStorageServiceServer(grpcServer, srv)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+	return lis.Addr().String()
+}
+
+// waitFor polls cond until it holds, failing the test after a while.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// nodeState returns the state ListNodes reports for addr, or "" if it is not
+// part of the cluster.
+func nodeState(t *testing.T, s *NetworkVideoContentService, addr string) string {
+	t.Helper()
+	resp, err := s.ListNodes(context.Background(), &proto.ListNodesRequest{})
+	if err != nil {
+		t.Fatalf("ListNodes: %v", err)
+	}
+	for _, st := range resp.Statuses {
+		if st.Address == addr {
+			return st.State
+		}
+	}
+	return ""
+}
+
+// waitIdle waits until s has no rebalance running.
+func waitIdle(t *testing.T, s *NetworkVideoContentService) {
+	t.Helper()
+	waitFor(t, "the rebalance to finish", func() bool {
+		s.opMu.Lock()
+		defer s.opMu.Unlock()
+		return s.activeOp == nil
+	})
+}
+
+func newRegistryService(t *testing.T, endpoint string, seeds ...string) *NetworkVideoContentService {
+	t.Helper()
+	s, err := NewNetworkVideoContentService(freeAddr(t), seeds, WithNodeRegistry([]string{endpoint}, testRegistryPrefix))
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	return s
+}
+
+func TestRegistryAddsAndDrainsNodes(t *testing.T) {
+	endpoint := startEtcd(t)
+	cli := newEtcdClient(t, endpoint)
+	seed, node := startStorageNode(t), startStorageNode(t)
+	s := newRegistryService(t, endpoint, seed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := storage.Register(ctx, cli, testRegistryPrefix, node, 5); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	waitFor(t, "the registered node to join", func() bool { return nodeState(t, s, node) == nodeActive })
+
+	// Losing the registration is what an expired lease looks like.
+	if _, err := cli.Delete(context.Background(), testRegistryPrefix+node); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the expired node to be drained", func() bool { return nodeState(t, s, node) == nodeDrained })
+	if got := nodeState(t, s, seed); got != nodeActive {
+		t.Errorf("seed node state = %q, want %q", got, nodeActive)
+	}
+}
+
+func TestRegistryListsAgainAfterWatchEnds(t *testing.T) {
+	endpoint := startEtcd(t)
+	cli := newEtcdClient(t, endpoint)
+	seed, first, second := startStorageNode(t), startStorageNode(t), startStorageNode(t)
+	s := newRegistryService(t, endpoint, seed)
+
+	if _, err := cli.Put(context.Background(), testRegistryPrefix+first, first); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the first node to join", func() bool { return nodeState(t, s, first) == nodeActive })
+
+	// Closing the watcher ends the registry's watch the way a compaction or a
+	// lost connection does. The registry must list again to see the second
+	// node, which registers before the next watch starts.
+	old := s.registry.cli.Watcher
+	s.registry.cli.Watcher = clientv3.NewWatcher(s.registry.cli)
+	if _, err := cli.Put(context.Background(), testRegistryPrefix+second, second); err != nil {
+		t.Fatal(err)
+	}
+	old.Close()
+	waitFor(t, "the second node to join", func() bool { return nodeState(t, s, second) == nodeActive })
+
+	if _, err := cli.Delete(context.Background(), testRegistryPrefix+second); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the second node to be drained", func() bool { return nodeState(t, s, second) == nodeDrained })
+}
+
+func TestRegistrySkipsDecommissionedNodes(t *testing.T) {
+	endpoint := startEtcd(t)
+	cli := newEtcdClient(t, endpoint)
+	seed, node := startStorageNode(t), startStorageNode(t)
+	s := newRegistryService(t, endpoint, seed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := storage.Register(ctx, cli, testRegistryPrefix, node, 5); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	waitFor(t, "the registered node to join", func() bool { return nodeState(t, s, node) == nodeActive })
+	waitIdle(t, s)
+
+	if _, err := s.RemoveNode(context.Background(), &proto.RemoveNodeRequest{NodeAddress: node}); err != nil {
+		t.Fatalf("RemoveNode: %v", err)
+	}
+	waitFor(t, "the node to be decommissioned", func() bool { return nodeState(t, s, node) == "" })
+
+	// The node's lease is still alive, but it must not be added back.
+	time.Sleep(3 * registryRetryInterval)
+	if got := nodeState(t, s, node); got != "" {
+		t.Fatalf("decommissioned node state = %q, want it gone", got)
+	}
+	resp, err := cli.Get(context.Background(), testRegistryPrefix, clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kv := range resp.Kvs {
+		if string(kv.Key) == testRegistryPrefix+node {
+			t.Errorf("registration of decommissioned node %s was kept", node)
+		}
+	}
+
+	// Registering again is how a decommissioned node asks to rejoin.
+	if _, err := cli.Put(context.Background(), testRegistryPrefix+node, node); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the node to rejoin", func() bool { return nodeState(t, s, node) == nodeActive })
+}
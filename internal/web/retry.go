@@ -0,0 +1,202 @@
+package web
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"math/rand"
+	"time"
+
+	"tritontube/internal/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Counters for storage RPC retries and hedged reads, published at /debug/vars.
+var (
+	storageRetries   = expvar.NewInt("storage_rpc_retries")
+	storageHedges    = expvar.NewInt("storage_read_hedges")
+	storageHedgeWins = expvar.NewInt("storage_read_hedge_wins")
+)
+
+// RetryPolicy controls how idempotent storage RPCs are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomizes each backoff by up to this fraction in either direction.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is used unless WithRetryPolicy says otherwise.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 50 * time.Millisecond,
+	MaxBackoff:     time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// WithRetryPolicy sets the retry policy for storage reads and writes. A
+// MaxAttempts of 1 disables retries.
+func WithRetryPolicy(p RetryPolicy) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		if p.MaxAttempts < 1 {
+			p.MaxAttempts = 1
+		}
+		if p.Multiplier < 1 {
+			p.Multiplier = 1
+		}
+		s.retry = p
+	}
+}
+
+// WithHedgedReads sends a second read to another node when the first has not
+// answered within after: the file's previous owner while a rebalance may not
+// have moved it yet, otherwise the next node round the ring. Zero disables
+// hedging.
+func WithHedgedReads(after time.Duration) NetworkOption {
+	return func(s *NetworkVideoContentService) {
+		s.hedgeAfter = after
+	}
+}
+
+// retryable reports whether err is a transient failure worth retrying.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+func (p RetryPolicy) backoff(d time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + p.Jitter*(2*rand.Float64()-1)))
+}
+
+// withRetry calls fn until it succeeds, fails with a non-transient error or
+// runs out of attempts, backing off exponentially between attempts.
+func (s *NetworkVideoContentService) withRetry(ctx context.Context, what string, fn func(ctx context.Context) error) error {
+	p := s.retry
+	backoff := p.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+		storageRetries.Add(1)
+		d := p.backoff(backoff)
+		log.Printf("DEBUG: %s failed (attempt %d/%d), retrying in %v: %v", what, attempt, p.MaxAttempts, d, err)
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		backoff = time.Duration(float64(backoff) * p.Multiplier)
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
+
+// readFile reads a file from one node, retrying transient failures.
+func (s *NetworkVideoContentService) readFile(ctx context.Context, addr string, client proto.VideoStorageServiceClient, videoId, filename string) ([]byte, error) {
+	var data []byte
+	err := s.withRetry(ctx, "read "+videoId+"/"+filename+" from "+addr, func(ctx context.Context) error {
+		resp, err := client.ReadFile(ctx, &proto.ReadFileRequest{VideoId: videoId, Filename: filename})
+		if err != nil {
+			return err
+		}
+		data = resp.Data
+		return nil
+	})
+	return data, err
+}
+
+// hedgeTarget returns the node a slow read of videoId/filename from addr is
+// hedged to, or "" if there is no other node.
+func (s *NetworkVideoContentService) hedgeTarget(videoId, filename, addr string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if prev := s.ownerIn(s.prevRing, videoId, filename); prev != "" && prev != addr {
+		return prev
+	}
+	for _, n := range s.ring.lookupN(s.placement.keyFor(videoId, filename), 2) {
+		if n != addr {
+			return n
+		}
+	}
+	return ""
+}
+
+// hedgedRead reads a file from addr and, if hedging is on and the read is
+// still outstanding after the hedge delay, from the hedge target as well.
+// The first successful answer wins. It also returns the node it hedged to, or
+// "" if it did not hedge.
+func (s *NetworkVideoContentService) hedgedRead(ctx context.Context, addr string, client proto.VideoStorageServiceClient, videoId, filename string) ([]byte, string, error) {
+	if s.hedgeAfter <= 0 {
+		data, err := s.readFile(ctx, addr, client, videoId, filename)
+		return data, "", err
+	}
+	hedge := s.hedgeTarget(videoId, filename, addr)
+	if hedge == "" {
+		data, err := s.readFile(ctx, addr, client, videoId, filename)
+		return data, "", err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		data   []byte
+		err    error
+		hedged bool
+	}
+	results := make(chan result, 2)
+	go func() {
+		data, err := s.readFile(ctx, addr, client, videoId, filename)
+		results <- result{data, err, false}
+	}()
+
+	timer := time.NewTimer(s.hedgeAfter)
+	defer timer.Stop()
+	pending := 1
+	hedged := ""
+	var firstErr error
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			hc, err := s.clientFor(hedge)
+			if err != nil {
+				continue
+			}
+			hedged = hedge
+			storageHedges.Add(1)
+			log.Printf("DEBUG: Read %s/%s from %s is slow, hedging to %s", videoId, filename, addr, hedge)
+			pending++
+			go func() {
+				data, err := s.readFile(ctx, hedge, hc, videoId, filename)
+				results <- result{data, err, true}
+			}()
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				if r.hedged {
+					storageHedgeWins.Add(1)
+				}
+				return r.data, hedged, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+		}
+	}
+	return nil, hedged, firstErr
+}
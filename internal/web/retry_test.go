@@ -0,0 +1,114 @@
+package web
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tritontube/internal/proto"
+	"tritontube/internal/storage"
+
+	"google.golang.org/grpc"
+)
+
+// slowNode is a storage node that counts its reads and can be made slow.
+type slowNode struct {
+	addr  string
+	reads atomic.Int64
+	delay atomic.Int64 // nanoseconds added to every read
+}
+
+func startSlowNode(t *testing.T) *slowNode {
+	t.Helper()
+	n := &slowNode{}
+	srv, err := storage.NewStorageServer(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create storage server: %v", err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, ok := req.(*proto.ReadFileRequest); ok {
+			n.reads.Add(1)
+			time.Sleep(time.Duration(n.delay.Load()))
+		}
+		return handler(ctx, req)
+	}))
+	proto.RegisterVideoStorageServiceServer(grpcServer, srv)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+	n.addr = lis.Addr().String()
+	return n
+}
+
+// ownerAndOther returns the node that owns videoId/filename in s's ring and
+// the other of the two.
+func ownerAndOther(s *NetworkVideoContentService, a, b *slowNode, videoId, filename string) (*slowNode, *slowNode) {
+	s.mu.RLock()
+	owner := s.ring.lookup(s.placement.keyFor(videoId, filename))
+	s.mu.RUnlock()
+	if owner == a.addr {
+		return a, b
+	}
+	return b, a
+}
+
+func TestHedgedReadGoesToNextNode(t *testing.T) {
+	a, b := startSlowNode(t), startSlowNode(t)
+	s, err := NewNetworkVideoContentService(freeAddr(t), []string{a.addr, b.addr}, WithHedgedReads(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	owner, next := ownerAndOther(s, a, b, "video", "manifest.mpd")
+	owner.delay.Store(int64(time.Second))
+
+	// Only the next node round the ring has a copy.
+	client, err := s.clientFor(next.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.WriteFile(context.Background(), &proto.WriteFileRequest{VideoId: "video", Filename: "manifest.mpd", Data: []byte("manifest")}); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	hedges := storageHedges.Value()
+	start := time.Now()
+	data, err := s.Read("video", "manifest.mpd")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if string(data) != "manifest" {
+		t.Errorf("Read = %q, want %q", data, "manifest")
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("Read took %v, want the hedge to answer before the slow owner", elapsed)
+	}
+	if got := storageHedges.Value() - hedges; got != 1 {
+		t.Errorf("hedges = %d, want 1", got)
+	}
+}
+
+func TestHedgedReadIsNotRepeatedAsFallback(t *testing.T) {
+	a, b := startSlowNode(t), startSlowNode(t)
+	s, err := NewNetworkVideoContentService(freeAddr(t), []string{a.addr, b.addr}, WithHedgedReads(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to create service: %v", err)
+	}
+	owner, prev := ownerAndOther(s, a, b, "video", "manifest.mpd")
+	owner.delay.Store(int64(100 * time.Millisecond))
+	// A rebalance is moving the file away from prev.
+	s.mu.Lock()
+	s.prevRing = newHashRing([]string{prev.addr})
+	s.mu.Unlock()
+
+	if _, err := s.Read("video", "manifest.mpd"); err == nil {
+		t.Fatal("Read of a file no node has succeeded")
+	}
+	if got := prev.reads.Load(); got != 1 {
+		t.Errorf("previous owner was read %d times, want once by the hedge", got)
+	}
+}
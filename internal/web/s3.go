@@ -0,0 +1,268 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"tritontube/internal/pathcheck"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3 requires every part of a multipart upload but the last to be at least
+// 5 MiB, and allows at most 10000 parts.
+const (
+	DefaultS3PartSize = 16 << 20
+	minS3PartSize     = 5 << 20
+	maxS3Parts        = 10000
+	s3DeleteBatch     = 1000
+)
+
+// S3VideoContentService stores video content as objects in an S3 compatible
+// bucket, at prefix/videoId/filename.
+type S3VideoContentService struct {
+	client   *s3.Client
+	bucket   string
+	prefix   string
+	partSize int64
+
+	endpoint  string
+	region    string
+	pathStyle bool
+}
+
+var _ VideoContentService = (*S3VideoContentService)(nil)
+
+// S3Option configures optional behaviour of an S3VideoContentService.
+type S3Option func(*S3VideoContentService)
+
+// WithS3Endpoint sends requests to endpoint, such as a MinIO server, instead of
+// AWS. Most such servers also need WithS3PathStyle.
+func WithS3Endpoint(endpoint string) S3Option {
+	return func(s *S3VideoContentService) {
+		s.endpoint = endpoint
+	}
+}
+
+// WithS3Region sets the bucket's region, overriding the AWS configuration.
+func WithS3Region(region string) S3Option {
+	return func(s *S3VideoContentService) {
+		s.region = region
+	}
+}
+
+// WithS3PathStyle addresses the bucket as endpoint/bucket rather than as
+// bucket.endpoint.
+func WithS3PathStyle(pathStyle bool) S3Option {
+	return func(s *S3VideoContentService) {
+		s.pathStyle = pathStyle
+	}
+}
+
+// WithS3PartSize sets the size of each part of a multipart upload. Files no
+// larger than this are uploaded in a single request.
+func WithS3PartSize(size int64) S3Option {
+	return func(s *S3VideoContentService) {
+		if size < minS3PartSize {
+			size = minS3PartSize
+		}
+		s.partSize = size
+	}
+}
+
+// NewS3VideoContentService creates a service that stores video content in
+// bucket under prefix. Credentials and, unless WithS3Region is given, the
+// region come from the standard AWS environment variables and config files.
+func NewS3VideoContentService(bucket, prefix string, opts ...S3Option) (*S3VideoContentService, error) {
+	if bucket == "" {
+		return nil, errors.New("no S3 bucket given")
+	}
+	svc := &S3VideoContentService{
+		bucket:   bucket,
+		prefix:   strings.Trim(prefix, "/"),
+		partSize: DefaultS3PartSize,
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	if svc.prefix != "" {
+		svc.prefix += "/"
+	}
+
+	ctx := context.Background()
+	var loadOpts []func(*config.LoadOptions) error
+	if svc.region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(svc.region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	svc.client = s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if svc.endpoint != "" {
+			o.BaseEndpoint = aws.String(svc.endpoint)
+		}
+		o.UsePathStyle = svc.pathStyle
+	})
+
+	if _, err := svc.client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		return nil, fmt.Errorf("failed to access bucket %s: %w", bucket, err)
+	}
+	log.Printf("DEBUG: Creating S3VideoContentService for s3://%s/%s (endpoint %q)", bucket, svc.prefix, svc.endpoint)
+	return svc, nil
+}
+
+func (s *S3VideoContentService) key(videoId, filename string) string {
+	return s.prefix + videoId + "/" + filename
+}
+
+func (s *S3VideoContentService) Write(videoId string, filename string, data []byte) error {
+	if err := pathcheck.Validate(videoId, filename); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	key := s.key(videoId, filename)
+	if int64(len(data)) <= s.partSize {
+		_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:        aws.String(s.bucket),
+			Key:           aws.String(key),
+			Body:          bytes.NewReader(data),
+			ContentLength: aws.Int64(int64(len(data))),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to put %s: %w", key, err)
+		}
+		return nil
+	}
+	return s.writeMultipart(ctx, key, data)
+}
+
+// writeMultipart uploads data in parts of partSize, growing them if needed to
+// stay within S3's part limit. The upload is aborted if any part fails, so no
+// incomplete parts are left billed in the bucket.
+func (s *S3VideoContentService) writeMultipart(ctx context.Context, key string, data []byte) error {
+	partSize := s.partSize
+	if n := (int64(len(data)) + maxS3Parts - 1) / maxS3Parts; n > partSize {
+		partSize = n
+	}
+	created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload of %s: %w", key, err)
+	}
+	abort := func(cause error) error {
+		_, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucket),
+			Key:      aws.String(key),
+			UploadId: created.UploadId,
+		})
+		if err != nil {
+			log.Printf("DEBUG: Failed to abort multipart upload of %s: %v", key, err)
+		}
+		return cause
+	}
+
+	var parts []types.CompletedPart
+	for off, n := int64(0), int32(1); off < int64(len(data)); off, n = off+partSize, n+1 {
+		part := data[off:min(off+partSize, int64(len(data)))]
+		resp, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(s.bucket),
+			Key:           aws.String(key),
+			UploadId:      created.UploadId,
+			PartNumber:    aws.Int32(n),
+			Body:          bytes.NewReader(part),
+			ContentLength: aws.Int64(int64(len(part))),
+		})
+		if err != nil {
+			return abort(fmt.Errorf("failed to upload part %d of %s: %w", n, key, err))
+		}
+		parts = append(parts, types.CompletedPart{ETag: resp.ETag, PartNumber: aws.Int32(n)})
+	}
+
+	_, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        created.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to complete multipart upload of %s: %w", key, err))
+	}
+	log.Printf("DEBUG: Uploaded %s in %d parts (%d bytes)", key, len(parts), len(data))
+	return nil
+}
+
+func (s *S3VideoContentService) Read(videoId string, filename string) ([]byte, error) {
+	if err := pathcheck.Validate(videoId, filename); err != nil {
+		return nil, err
+	}
+	key := s.key(videoId, filename)
+	resp, err := s.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// Delete removes every object under the video's prefix.
+func (s *S3VideoContentService) Delete(videoId string) error {
+	if err := pathcheck.ValidateVideoID(videoId); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	prefix := s.prefix + videoId + "/"
+	pages := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int32(s3DeleteBatch),
+	})
+	deleted := 0
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", prefix, err)
+		}
+		if len(page.Contents) == 0 {
+			continue
+		}
+		objects := make([]types.ObjectIdentifier, len(page.Contents))
+		for i, obj := range page.Contents {
+			objects[i] = types.ObjectIdentifier{Key: obj.Key}
+		}
+		resp, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.bucket),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete objects under %s: %w", prefix, err)
+		}
+		if len(resp.Errors) > 0 {
+			e := resp.Errors[0]
+			return fmt.Errorf("failed to delete %s: %s", aws.ToString(e.Key), aws.ToString(e.Message))
+		}
+		deleted += len(objects)
+	}
+	log.Printf("DEBUG: Deleted %d objects under s3://%s/%s", deleted, s.bucket, prefix)
+	return nil
+}
@@ -0,0 +1,161 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+)
+
+const testS3Bucket = "tritontube-test"
+
+// fakeS3 is an in-process S3 server that counts the multipart parts uploaded
+// to it.
+type fakeS3 struct {
+	url   string
+	parts atomic.Int64
+}
+
+func startFakeS3(t *testing.T) *fakeS3 {
+	t.Helper()
+	backend := s3mem.New()
+	if err := backend.CreateBucket(testS3Bucket); err != nil {
+		t.Fatalf("failed to create bucket: %v", err)
+	}
+	f := &fakeS3{}
+	handler := gofakes3.New(backend).Server()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && r.URL.Query().Has("partNumber") {
+			f.parts.Add(1)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	f.url = srv.URL
+	return f
+}
+
+func newTestS3Service(t *testing.T, f *fakeS3, opts ...S3Option) *S3VideoContentService {
+	t.Helper()
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+	opts = append([]S3Option{WithS3Endpoint(f.url), WithS3Region("us-east-1"), WithS3PathStyle(true)}, opts...)
+	s, err := NewS3VideoContentService(testS3Bucket, "content", opts...)
+	if err != nil {
+		t.Fatalf("NewS3VideoContentService: %v", err)
+	}
+	return s
+}
+
+func TestS3WriteRead(t *testing.T) {
+	f := startFakeS3(t)
+	s := newTestS3Service(t, f)
+
+	if err := s.Write("video", "manifest.mpd", []byte("<MPD/>")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	got, err := s.Read("video", "manifest.mpd")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if string(got) != "<MPD/>" {
+		t.Errorf("Read = %q, want %q", got, "<MPD/>")
+	}
+	if n := f.parts.Load(); n != 0 {
+		t.Errorf("small file uploaded in %d parts, want a single put", n)
+	}
+
+	// Objects are stored under the prefix.
+	_, err = s.client.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String(testS3Bucket),
+		Key:    aws.String("content/video/manifest.mpd"),
+	})
+	if err != nil {
+		t.Errorf("HeadObject content/video/manifest.mpd: %v", err)
+	}
+}
+
+func TestS3MultipartWrite(t *testing.T) {
+	f := startFakeS3(t)
+	s := newTestS3Service(t, f, WithS3PartSize(minS3PartSize))
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), (2*minS3PartSize+minS3PartSize/2)/16)
+	if err := s.Write("video", "chunk-stream0-00001.m4s", data); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if n := f.parts.Load(); n != 3 {
+		t.Errorf("uploaded %d parts, want 3", n)
+	}
+	got, err := s.Read("video", "chunk-stream0-00001.m4s")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("Read returned %d bytes that differ from the %d written", len(got), len(data))
+	}
+}
+
+func TestS3Delete(t *testing.T) {
+	f := startFakeS3(t)
+	s := newTestS3Service(t, f)
+
+	for _, name := range []string{"manifest.mpd", "init-stream0.m4s", "chunk-stream0-00001.m4s"} {
+		if err := s.Write("video", name, []byte(name)); err != nil {
+			t.Fatalf("Write %s: %v", name, err)
+		}
+	}
+	if err := s.Write("video2", "manifest.mpd", []byte("other")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := s.Delete("video"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	resp, err := s.client.ListObjectsV2(context.Background(), &s3.ListObjectsV2Input{
+		Bucket: aws.String(testS3Bucket),
+		Prefix: aws.String("content/"),
+	})
+	if err != nil {
+		t.Fatalf("ListObjectsV2: %v", err)
+	}
+	var keys []string
+	for _, obj := range resp.Contents {
+		keys = append(keys, aws.ToString(obj.Key))
+	}
+	if len(keys) != 1 || keys[0] != "content/video2/manifest.mpd" {
+		t.Errorf("objects left = %v, want only content/video2/manifest.mpd", keys)
+	}
+	// Deleting a video with no objects is not an error.
+	if err := s.Delete("video"); err != nil {
+		t.Errorf("Delete of empty video: %v", err)
+	}
+}
+
+func TestS3ReadMissing(t *testing.T) {
+	f := startFakeS3(t)
+	s := newTestS3Service(t, f)
+
+	_, err := s.Read("video", "manifest.mpd")
+	if err == nil {
+		t.Fatal("Read of missing key succeeded")
+	}
+	var noKey *types.NoSuchKey
+	if !errors.As(err, &noKey) {
+		t.Errorf("Read = %v, want NoSuchKey", err)
+	}
+	if !strings.Contains(err.Error(), "content/video/manifest.mpd") {
+		t.Errorf("Read error %q does not name the key", err)
+	}
+}
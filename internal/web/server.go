@@ -2,6 +2,7 @@ package web
 
 import (
 	"bytes"
+	"expvar"
 	"html/template"
 	"io"
 	"log"
@@ -13,6 +14,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"tritontube/internal/pathcheck"
 )
 
 type server struct {
@@ -36,10 +39,20 @@ func NewServer(
 }
 
 func (s *server) Start(lis net.Listener) error {
+	s.recoverStaleUploads()
+	// Uploads interrupted shortly before this start are settled once they
+	// are old enough.
+	go func() {
+		for range time.Tick(uploadRecoveryGrace) {
+			s.recoverStaleUploads()
+		}
+	}()
+
 	s.mux = http.NewServeMux()
 	s.mux.HandleFunc("/upload", s.handleUpload)
 	s.mux.HandleFunc("/videos/", s.handleVideo)
 	s.mux.HandleFunc("/content/", s.handleVideoContent)
+	s.mux.Handle("/debug/vars", expvar.Handler())
 	s.mux.HandleFunc("/", s.handleIndex)
 
 	return http.Serve(lis, s.mux)
@@ -54,7 +67,8 @@ func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
 
 	type VideoView struct {
 		Id         string
-		EscapedId  string
+		Link       string
+		Title      string
 		UploadTime string
 	}
 
@@ -62,7 +76,8 @@ func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
 	for _, v := range videos {
 		viewData = append(viewData, VideoView{
 			Id:         v.Id,
-			EscapedId:  url.PathEscape(v.Id), // Changed from template.URLQueryEscaper
+			Link:       videoLink(v),
+			Title:      v.Title(),
 			UploadTime: v.UploadedAt.Format(time.RFC822),
 		})
 	}
@@ -84,18 +99,18 @@ func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	videoId := strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename))
-	if videoId == "" {
-		http.Error(w, "Invalid filename", http.StatusBadRequest)
-		return
-	}
-
-	// Check if videoId exists
-	existing, _ := s.metadataService.Read(videoId)
-	if existing != nil {
-		http.Error(w, "Video ID already exists", http.StatusConflict)
+	videoId, err := s.reserveVideoId(header.Filename)
+	if err != nil {
+		log.Printf("Failed to reserve video ID for %s: %v", header.Filename, err)
+		http.Error(w, "Server Error", http.StatusInternalServerError)
 		return
 	}
+	committed := false
+	defer func() {
+		if !committed {
+			s.rollbackUpload(videoId)
+		}
+	}()
 
 	tempDir, err := os.MkdirTemp("", "tritontube-*")
 	if err != nil {
@@ -105,7 +120,7 @@ func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
 	defer os.RemoveAll(tempDir)
 
 	inputPath := filepath.Join(tempDir, "input.mp4")
-	outputPath := filepath.Join(tempDir, "manifest.mpd")
+	outputPath := filepath.Join(tempDir, manifestFile)
 
 	inFile, err := os.Create(inputPath)
 	if err != nil {
@@ -143,8 +158,9 @@ func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The manifest is written last; see recoverUploads.
 	err = filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
+		if err != nil || info.IsDir() || path == outputPath {
 			return err
 		}
 		data, err := os.ReadFile(path)
@@ -154,22 +170,45 @@ func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
 		filename := filepath.Base(path)
 		return s.contentService.Write(videoId, filename, data)
 	})
+	if err == nil {
+		var data []byte
+		if data, err = os.ReadFile(outputPath); err == nil {
+			err = s.contentService.Write(videoId, manifestFile, data)
+		}
+	}
 	if err != nil {
+		log.Printf("Failed to save content of %s: %v", videoId, err)
 		http.Error(w, "Failed to save video content", http.StatusInternalServerError)
 		return
 	}
 
 	err = s.metadataService.Create(videoId, time.Now())
 	if err != nil {
+		log.Printf("Failed to save metadata of %s: %v", videoId, err)
 		http.Error(w, "Failed to save metadata", http.StatusInternalServerError)
 		return
 	}
+	committed = true
 
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
+// videoLink returns the page URL of a video, with its slug for readability.
+func videoLink(v VideoMetadata) string {
+	link := "/videos/" + url.PathEscape(v.Id) // Changed from template.URLQueryEscaper
+	if v.Slug != "" {
+		link += "/" + url.PathEscape(v.Slug)
+	}
+	return link
+}
+
 func (s *server) handleVideo(w http.ResponseWriter, r *http.Request) {
-	videoId := strings.TrimPrefix(r.URL.Path, "/videos/")
+	// The path is /videos/{id}, optionally followed by /{slug}.
+	videoId, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/videos/"), "/")
+	if err := pathcheck.ValidateVideoID(videoId); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	video, err := s.metadataService.Read(videoId)
 	if err != nil {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -192,6 +231,10 @@ func (s *server) handleVideoContent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	videoId, filename := parts[0], parts[1]
+	if err := pathcheck.Validate(videoId, filename); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	data, err := s.contentService.Read(videoId, filename)
 	if err != nil {
@@ -4,10 +4,12 @@ package web
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 type SQLiteVideoMetadataService struct {
@@ -26,18 +28,54 @@ func NewSQLiteVideoMetadataService(dbPath string) (*SQLiteVideoMetadataService,
 	createTableQuery := `
 	CREATE TABLE IF NOT EXISTS videos (
 		id TEXT PRIMARY KEY,
-		uploaded_at TIMESTAMP NOT NULL
+		uploaded_at TIMESTAMP NOT NULL,
+		filename TEXT NOT NULL DEFAULT '',
+		slug TEXT NOT NULL DEFAULT '',
+		ready INTEGER NOT NULL DEFAULT 1
 	);`
 	if _, err := db.Exec(createTableQuery); err != nil {
 		return nil, fmt.Errorf("failed to create table: %w", err)
 	}
+	// Databases created before uploads were reserved lack these columns.
+	for _, column := range []string{
+		"filename TEXT NOT NULL DEFAULT ''",
+		"slug TEXT NOT NULL DEFAULT ''",
+		"ready INTEGER NOT NULL DEFAULT 1",
+	} {
+		_, err := db.Exec("ALTER TABLE videos ADD COLUMN " + column)
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return nil, fmt.Errorf("failed to upgrade table: %w", err)
+		}
+	}
 
 	return &SQLiteVideoMetadataService{db: db}, nil
 }
 
-// Create inserts a new video metadata entry.
+// Reserve inserts a video metadata entry that is hidden until Create.
+func (s *SQLiteVideoMetadataService) Reserve(video VideoMetadata) error {
+	_, err := s.db.Exec("INSERT INTO videos (id, uploaded_at, filename, slug, ready) VALUES (?, ?, ?, ?, 0)",
+		video.Id, video.UploadedAt, video.Filename, video.Slug)
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey {
+		return ErrVideoExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reserve video id: %w", err)
+	}
+	return nil
+}
+
+// Create publishes a reserved video, or inserts a new video metadata entry if
+// none was reserved.
 func (s *SQLiteVideoMetadataService) Create(videoId string, uploadedAt time.Time) error {
-	_, err := s.db.Exec("INSERT INTO videos (id, uploaded_at) VALUES (?, ?)", videoId, uploadedAt)
+	res, err := s.db.Exec("UPDATE videos SET uploaded_at = ?, ready = 1 WHERE id = ? AND ready = 0", uploadedAt, videoId)
+	if err != nil {
+		return fmt.Errorf("failed to update video metadata: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		return nil
+	}
+	_, err = s.db.Exec("INSERT INTO videos (id, uploaded_at) VALUES (?, ?)", videoId, uploadedAt)
 	if err != nil {
 		return fmt.Errorf("failed to insert video metadata: %w", err)
 	}
@@ -46,7 +84,20 @@ func (s *SQLiteVideoMetadataService) Create(videoId string, uploadedAt time.Time
 
 // List returns all video metadata entries.
 func (s *SQLiteVideoMetadataService) List() ([]VideoMetadata, error) {
-	rows, err := s.db.Query("SELECT id, uploaded_at FROM videos ORDER BY uploaded_at DESC")
+	return s.list(1)
+}
+
+// ListPending returns the reserved entries, oldest first.
+func (s *SQLiteVideoMetadataService) ListPending() ([]VideoMetadata, error) {
+	return s.list(0)
+}
+
+func (s *SQLiteVideoMetadataService) list(ready int) ([]VideoMetadata, error) {
+	order := "DESC"
+	if ready == 0 {
+		order = "ASC"
+	}
+	rows, err := s.db.Query("SELECT id, uploaded_at, filename, slug FROM videos WHERE ready = ? ORDER BY uploaded_at "+order, ready)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query video metadata: %w", err)
 	}
@@ -56,7 +107,7 @@ func (s *SQLiteVideoMetadataService) List() ([]VideoMetadata, error) {
 	for rows.Next() {
 		var v VideoMetadata
 		var uploadedAt string
-		if err := rows.Scan(&v.Id, &uploadedAt); err != nil {
+		if err := rows.Scan(&v.Id, &uploadedAt, &v.Filename, &v.Slug); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 		v.UploadedAt, _ = time.Parse(time.RFC3339, uploadedAt)
@@ -72,7 +123,8 @@ func (s *SQLiteVideoMetadataService) List() ([]VideoMetadata, error) {
 func (s *SQLiteVideoMetadataService) Read(videoId string) (*VideoMetadata, error) {
 	var v VideoMetadata
 	var uploadedAt string
-	err := s.db.QueryRow("SELECT id, uploaded_at FROM videos WHERE id = ?", videoId).Scan(&v.Id, &uploadedAt)
+	err := s.db.QueryRow("SELECT id, uploaded_at, filename, slug FROM videos WHERE id = ? AND ready = 1", videoId).
+		Scan(&v.Id, &uploadedAt, &v.Filename, &v.Slug)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -83,6 +135,14 @@ func (s *SQLiteVideoMetadataService) Read(videoId string) (*VideoMetadata, error
 	return &v, nil
 }
 
+// Delete removes a video metadata entry.
+func (s *SQLiteVideoMetadataService) Delete(videoId string) error {
+	if _, err := s.db.Exec("DELETE FROM videos WHERE id = ?", videoId); err != nil {
+		return fmt.Errorf("failed to delete video metadata: %w", err)
+	}
+	return nil
+}
+
 func (s *SQLiteVideoMetadataService) Close() error {
 	return s.db.Close()
 }
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"tritontube/internal/proto"
+)
+
+// topVideoCount is how many of the largest videos ListNodes reports.
+const topVideoCount = 10
+
+// collectStats asks every node for its usage in parallel, fills in each
+// status and returns the cluster-wide totals. Nodes that do not answer are
+// reported with an error and left out of the totals.
+func (s *NetworkVideoContentService) collectStats(ctx context.Context, statuses []*proto.NodeStatus) *proto.ClusterUsage {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, st := range statuses {
+		wg.Add(1)
+		go func(st *proto.NodeStatus) {
+			defer wg.Done()
+			client, err := s.clientFor(st.Address)
+			if err == nil {
+				st.Stats, err = client.GetStats(ctx, &proto.GetStatsRequest{})
+			}
+			if err != nil {
+				st.StatsError = err.Error()
+			}
+		}(st)
+	}
+	wg.Wait()
+
+	usage := &proto.ClusterUsage{}
+	videos := make(map[string]*proto.VideoUsage)
+	for _, st := range statuses {
+		if st.Stats == nil {
+			continue
+		}
+		usage.FileCount += st.Stats.FileCount
+		usage.BytesUsed += st.Stats.BytesUsed
+		if st.Stats.StoredBytes > 0 || st.Stats.BytesUsed == 0 {
+			usage.StoredBytes += st.Stats.StoredBytes
+		} else {
+			// Nodes that predate deduplication store every byte they use.
+			usage.StoredBytes += st.Stats.BytesUsed
+		}
+		usage.FreeBytes += st.Stats.FreeBytes
+		usage.TotalBytes += st.Stats.TotalBytes
+		for _, v := range st.Stats.Videos {
+			total, ok := videos[v.VideoId]
+			if !ok {
+				total = &proto.VideoUsage{VideoId: v.VideoId}
+				videos[v.VideoId] = total
+			}
+			total.Files += v.Files
+			total.Bytes += v.Bytes
+		}
+	}
+	usage.DedupRatio = 1
+	if usage.StoredBytes > 0 {
+		usage.DedupRatio = float64(usage.BytesUsed) / float64(usage.StoredBytes)
+	}
+	for _, v := range videos {
+		usage.TopVideos = append(usage.TopVideos, v)
+	}
+	sort.Slice(usage.TopVideos, func(i, j int) bool {
+		a, b := usage.TopVideos[i], usage.TopVideos[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.VideoId < b.VideoId
+	})
+	if len(usage.TopVideos) > topVideoCount {
+		usage.TopVideos = usage.TopVideos[:topVideoCount]
+	}
+	return usage
+}
@@ -20,7 +20,7 @@ const indexHTML = `
     <ul>
       {{range .}}
       <li>
-        <a href="/videos/{{.EscapedId}}">{{.Id}} ({{.UploadTime}})</a>
+        <a href="{{.Link}}">{{.Title}} ({{.UploadTime}})</a>
       </li>
       {{else}}
       <li>No videos uploaded yet.</li>
@@ -35,11 +35,11 @@ const videoHTML = `
 <html>
   <head>
     <meta charset="UTF-8" />
-    <title>{{.Id}} - TritonTube</title>
+    <title>{{.Title}} - TritonTube</title>
     <script src="https://cdn.dashjs.org/latest/dash.all.min.js"></script>
   </head>
   <body>
-    <h1>{{.Id}}</h1>
+    <h1>{{.Title}}</h1>
 	  <p>Uploaded at: {{.UploadedAt}}</p>
 
     <video id="dashPlayer" controls style="width: 640px; height: 360px"></video>
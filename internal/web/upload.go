@@ -0,0 +1,92 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+)
+
+// manifestFile is written last by an upload, so its presence means all of a
+// video's content was stored.
+const manifestFile = "manifest.mpd"
+
+// reserveIdAttempts bounds how often handleUpload draws a new video ID after
+// a collision.
+const reserveIdAttempts = 5
+
+// reserveVideoId claims a new random video ID for an upload of filename.
+func (s *server) reserveVideoId(filename string) (string, error) {
+	video := VideoMetadata{
+		UploadedAt: time.Now(),
+		Filename:   filepath.Base(filename),
+		Slug:       slugify(filepath.Base(filename)),
+	}
+	for attempt := 0; attempt < reserveIdAttempts; attempt++ {
+		id, err := newVideoId()
+		if err != nil {
+			return "", err
+		}
+		video.Id = id
+		err = s.metadataService.Reserve(video)
+		if err == nil {
+			return id, nil
+		}
+		if !errors.Is(err, ErrVideoExists) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("no free video ID after %d attempts", reserveIdAttempts)
+}
+
+// rollbackUpload removes the content and reservation of an upload that failed.
+func (s *server) rollbackUpload(videoId string) {
+	log.Printf("Rolling back upload of %s", videoId)
+	if err := s.contentService.Delete(videoId); err != nil {
+		// Keep the reservation so the next recovery sweep retries the cleanup.
+		log.Printf("Failed to delete content of %s: %v", videoId, err)
+		return
+	}
+	if err := s.metadataService.Delete(videoId); err != nil {
+		log.Printf("Failed to delete reservation of %s: %v", videoId, err)
+	}
+}
+
+// uploadRecoveryGrace is how long a reservation stays pending before it is
+// taken for an interrupted upload. Like DefaultGCGrace, it leaves alone the
+// uploads still in flight, including other frontends' when they share the
+// metadata store.
+const uploadRecoveryGrace = DefaultGCGrace
+
+// recoverStaleUploads settles the uploads reserved more than
+// uploadRecoveryGrace ago.
+func (s *server) recoverStaleUploads() {
+	if err := s.recoverUploads(time.Now().Add(-uploadRecoveryGrace)); err != nil {
+		log.Printf("Failed to recover interrupted uploads: %v", err)
+	}
+}
+
+// recoverUploads settles the uploads reserved before cutoff that never
+// completed, typically because the server stopped mid-upload. Uploads whose
+// manifest was stored are published; the rest are rolled back.
+func (s *server) recoverUploads(cutoff time.Time) error {
+	pending, err := s.metadataService.ListPending()
+	if err != nil {
+		return err
+	}
+	for _, v := range pending {
+		if !v.UploadedAt.Before(cutoff) {
+			continue
+		}
+		if _, err := s.contentService.Read(v.Id, manifestFile); err == nil {
+			log.Printf("Finishing interrupted upload of %s", v.Id)
+			if err := s.metadataService.Create(v.Id, v.UploadedAt); err != nil {
+				log.Printf("Failed to finish upload of %s: %v", v.Id, err)
+			}
+			continue
+		}
+		s.rollbackUpload(v.Id)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package web
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRestartRecoversOnlyStaleUploads(t *testing.T) {
+	dir := t.TempDir()
+	metadata, err := NewSQLiteVideoMetadataService(filepath.Join(dir, "metadata.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteVideoMetadataService: %v", err)
+	}
+	content, err := NewFSVideoContentService(filepath.Join(dir, "content"))
+	if err != nil {
+		t.Fatalf("NewFSVideoContentService: %v", err)
+	}
+
+	stale := time.Now().Add(-2 * uploadRecoveryGrace)
+	for _, v := range []VideoMetadata{
+		// Another frontend is still processing this upload.
+		{Id: "fresh", UploadedAt: time.Now(), Filename: "fresh.mp4"},
+		{Id: "stored", UploadedAt: stale, Filename: "stored.mp4"},
+		{Id: "abandoned", UploadedAt: stale, Filename: "abandoned.mp4"},
+	} {
+		if err := metadata.Reserve(v); err != nil {
+			t.Fatalf("Reserve %s: %v", v.Id, err)
+		}
+	}
+	for _, id := range []string{"fresh", "stored"} {
+		if err := content.Write(id, manifestFile, []byte("manifest")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	// Start recovers interrupted uploads before serving, and returns once the
+	// closed listener stops it.
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis.Close()
+	NewServer(metadata, content).Start(lis)
+
+	pending, err := metadata.ListPending()
+	if err != nil {
+		t.Fatalf("ListPending: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Id != "fresh" {
+		t.Errorf("pending uploads = %v, want only the fresh reservation", pending)
+	}
+	if v, err := metadata.Read("stored"); err != nil || v == nil {
+		t.Errorf("stale upload with a manifest was not published: %v, %v", v, err)
+	}
+	if v, err := metadata.Read("abandoned"); err != nil || v != nil {
+		t.Errorf("stale upload without a manifest was not rolled back: %v, %v", v, err)
+	}
+	if _, err := content.Read("fresh", manifestFile); err != nil {
+		t.Errorf("content of the fresh upload was deleted: %v", err)
+	}
+}
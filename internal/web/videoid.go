@@ -0,0 +1,58 @@
+package web
+
+import (
+	"crypto/rand"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// videoIdLength is the number of base62 characters in a generated video ID,
+// about 59 bits of randomness.
+const videoIdLength = 10
+
+// maxSlugLength bounds the slug derived from an upload's filename.
+const maxSlugLength = 60
+
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// newVideoId returns a random base62 video ID.
+func newVideoId() (string, error) {
+	b := make([]byte, videoIdLength)
+	max := big.NewInt(int64(len(base62)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = base62[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// slugify turns an uploaded filename such as "My Trip (2024).mp4" into a
+// readable slug such as "my-trip-2024".
+func slugify(filename string) string {
+	stem := strings.TrimSuffix(filename, filepath.Ext(filename))
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(stem) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		} else {
+			dash = true
+		}
+		if b.Len() >= maxSlugLength {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "video"
+	}
+	return b.String()
+}
@@ -64,6 +64,16 @@ predict_node_for_file() {
     echo "${nodes[$node_index]}"
 }
 
+# Print the ID the server generated for an uploaded video file, read from its
+# link on the index page. Prints nothing if the file has not been uploaded.
+video_id_for() {
+    local video="$1"
+    curl -s "$BASE_URL/" 2>/dev/null \
+        | grep -F ">$video (" \
+        | sed -n 's|.*href="/videos/\([^/"]*\).*|\1|p' \
+        | head -n1 || true
+}
+
 # Logging functions
 log_info() {
     echo -e "${BLUE}[INFO]${NC} $1"
@@ -162,11 +172,6 @@ create_test_videos() {
             
             ffmpeg -f lavfi -i "testsrc=duration=$duration:size=$size:rate=1" \
                    -c:v libx264 -preset ultrafast "$video" -y > /dev/null 2>&1
-            
-            # Calculate and log the hash for this video's manifest
-            video_id="${video%.mp4}"
-            manifest_hash=$(calculate_hash "$video_id/manifest.mpd")
-            log_hash "Video $video_id/manifest.mpd has hash prefix: $manifest_hash"
         fi
     done
     
@@ -288,36 +293,36 @@ test_admin_list() {
 # Enhanced video upload with hash logging
 test_video_upload() {
     local video="$1"
-    local video_id="${video%.mp4}"
-    log_test "Testing upload of $video (ID: $video_id)..."
+    log_test "Testing upload of $video..."
     
     # Check if video already exists
-    local existing_code
-    existing_code=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/content/$video_id/manifest.mpd" 2>/dev/null || echo "404")
-    
-    if [ "$existing_code" == "200" ]; then
-        log_warning "Video $video_id already exists, skipping upload"
+    local video_id
+    video_id=$(video_id_for "$video")
+    if [ -n "$video_id" ]; then
+        log_warning "Video $video already exists (ID: $video_id), skipping upload"
         return 0
     fi
     
-    # Pre-calculate expected hash for manifest
-    local manifest_hash=$(calculate_hash "$video_id/manifest.mpd")
-    log_hash "Expected hash for $video_id/manifest.mpd: $manifest_hash"
-    
     local resp code
     resp=$(curl -s -w "\n%{http_code}" -F "file=@$video" "$BASE_URL/upload")
     code=$(echo "$resp" | tail -n1)
     
-    if [ "$code" == "409" ]; then
-        log_warning "Video $video_id already exists (409 Conflict), treating as success"
-        return 0
-    elif [ "$code" != "303" ]; then
+    if [ "$code" != "303" ]; then
         log_error "Upload of $video failed with code $code"
         echo "Response: $resp"
         return 1
     fi
     
-    log_success "Upload of $video successful"
+    # The server generates the video ID; find it on the index page.
+    video_id=$(video_id_for "$video")
+    if [ -z "$video_id" ]; then
+        log_error "Uploaded $video but it is not listed on the index page"
+        return 1
+    fi
+    local manifest_hash=$(calculate_hash "$video_id/manifest.mpd")
+    log_hash "Video $video got ID $video_id; $video_id/manifest.mpd has hash prefix: $manifest_hash"
+    
+    log_success "Upload of $video successful (ID: $video_id)"
     
     # Wait a moment for file processing
     sleep 2
@@ -433,8 +438,13 @@ test_node_addition() {
 # Test video access with hash verification
 test_video_access() {
     local video="$1"
-    local video_id="${video%.mp4}"
-    log_test "Testing access to $video_id manifest..."
+    local video_id
+    video_id=$(video_id_for "$video")
+    if [ -z "$video_id" ]; then
+        log_error "Video $video is not listed on the index page"
+        return 1
+    fi
+    log_test "Testing access to $video_id manifest ($video)..."
     
     # Calculate expected hash
     local manifest_hash=$(calculate_hash "$video_id/manifest.mpd")
@@ -467,11 +477,7 @@ test_consistent_hashing() {
     # Check which videos need to be uploaded
     local videos_to_upload=()
     for video in "${TEST_VIDEOS[@]:0:4}"; do
-        local video_id="${video%.mp4}"
-        local code
-        code=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/content/$video_id/manifest.mpd" 2>/dev/null || echo "404")
-        
-        if [ "$code" != "200" ]; then
+        if [ -z "$(video_id_for "$video")" ]; then
             videos_to_upload+=("$video")
         fi
     done
@@ -490,7 +496,11 @@ test_consistent_hashing() {
     log_info "Analyzing consistent hashing distribution pattern..."
     
     for video in "${TEST_VIDEOS[@]:0:4}"; do
-        video_id="${video%.mp4}"
+        video_id=$(video_id_for "$video")
+        if [ -z "$video_id" ]; then
+            log_warning "Video $video is not listed on the index page"
+            continue
+        fi
         manifest_hash=$(calculate_hash "$video_id/manifest.mpd")
         
         # Find which node actually has the file
@@ -515,14 +525,13 @@ test_detailed_migration() {
     # Check if videos are already uploaded from previous tests
     local videos_to_upload=()
     for video in "${TEST_VIDEOS[@]:0:3}"; do
-        local video_id="${video%.mp4}"
-        local code
-        code=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/content/$video_id/manifest.mpd" 2>/dev/null || echo "404")
+        local video_id
+        video_id=$(video_id_for "$video")
         
-        if [ "$code" != "200" ]; then
+        if [ -z "$video_id" ]; then
             videos_to_upload+=("$video")
         else
-            log_info "Video $video_id already exists, skipping upload"
+            log_info "Video $video already exists (ID: $video_id), skipping upload"
         fi
     done
     
@@ -69,6 +69,16 @@ record_test() {
 }
 
 # Hash calculation utility
+# Print the ID the server generated for an uploaded video file, read from its
+# link on the index page. Prints nothing if the file has not been uploaded.
+video_id_for() {
+    local video="$1"
+    curl -s "$BASE_URL/" 2>/dev/null \
+        | grep -F ">$video (" \
+        | sed -n 's|.*href="/videos/\([^/"]*\).*|\1|p' \
+        | head -n1 || true
+}
+
 calculate_hash() {
     echo -n "$1" | sha256sum | cut -c1-16
 }
@@ -128,14 +138,9 @@ create_test_videos() {
             ffmpeg -f lavfi -i "testsrc=duration=$duration:size=$size:rate=1" \
                    -c:v libx264 -preset ultrafast "$video" -y > /dev/null 2>&1
         fi
-        
-        # Log expected hash distribution
-        video_id="${video%.mp4}"
-        manifest_hash=$(calculate_hash "$video_id/manifest.mpd")
-        log_hash "Video $video_id/manifest.mpd expected hash: $manifest_hash"
     done
     
-    log_success "Test videos created with hash analysis"
+    log_success "Test videos created"
 }
 
 # Start all storage servers
@@ -255,7 +260,7 @@ test_upload_endpoint() {
     log_test "Testing POST /upload (All scenarios)"
     
     local video="${TEST_VIDEOS[0]}"
-    local video_id="${video%.mp4}"
+    local video_id
     
     # Test successful upload
     log_endpoint "POST /upload (success)"
@@ -267,18 +272,27 @@ test_upload_endpoint() {
         record_test "endpoint" "POST /upload (success)" "FAIL" "HTTP $code"
         return 1
     fi
-    record_test "endpoint" "POST /upload (success)" "PASS" "303 redirect"
+    video_id=$(video_id_for "$video")
+    if [ -z "$video_id" ]; then
+        record_test "endpoint" "POST /upload (success)" "FAIL" "$video not listed on the index page"
+        return 1
+    fi
+    record_test "endpoint" "POST /upload (success)" "PASS" "303 redirect, ID $video_id"
+    local manifest_hash=$(calculate_hash "$video_id/manifest.mpd")
+    log_hash "Video $video got ID $video_id; $video_id/manifest.mpd has hash prefix: $manifest_hash"
     
-    # Test duplicate upload
-    log_endpoint "POST /upload (duplicate)"
+    # Uploading the same file again creates a second video with its own ID
+    log_endpoint "POST /upload (same file again)"
     response=$(curl -s -w "\n%{http_code}" -F "file=@$video" "$BASE_URL/upload")
     code=$(echo "$response" | tail -n1)
     
-    if [ "$code" != "409" ]; then
-        record_test "endpoint" "POST /upload (duplicate)" "FAIL" "HTTP $code"
+    local copies
+    copies=$(curl -s "$BASE_URL/" | grep -cF ">$video (" || true)
+    if [ "$code" != "303" ] || [ "$copies" -lt 2 ]; then
+        record_test "endpoint" "POST /upload (same file again)" "FAIL" "HTTP $code, $copies videos listed"
         return 1
     fi
-    record_test "endpoint" "POST /upload (duplicate)" "PASS" "409 conflict"
+    record_test "endpoint" "POST /upload (same file again)" "PASS" "303 redirect, new ID"
     
     # Test upload without file
     log_endpoint "POST /upload (no file)"
@@ -296,7 +310,8 @@ test_upload_endpoint() {
 test_video_page_endpoint() {
     log_test "Testing GET /videos/:videoId"
     
-    local video_id="${TEST_VIDEOS[0]%.mp4}"
+    local video_id
+    video_id=$(video_id_for "${TEST_VIDEOS[0]}")
     
     # Test existing video
     log_endpoint "GET /videos/:videoId (existing)"
@@ -333,7 +348,8 @@ test_video_page_endpoint() {
 test_content_endpoint() {
     log_test "Testing GET /content/:videoId/:filename (comprehensive)"
     
-    local video_id="${TEST_VIDEOS[0]%.mp4}"
+    local video_id
+    video_id=$(video_id_for "${TEST_VIDEOS[0]}")
     sleep 3  # Wait for video processing
     
     # Test manifest.mpd
@@ -526,7 +542,7 @@ test_node_removal_with_migration() {
 test_video_access_after_operations() {
     local video="$1"
     local operation="$2"
-    local video_id="${video%.mp4}"
+    local video_id=$(video_id_for "$video")
     
     log_test "Testing $video_id accessibility after $operation"
     
@@ -562,7 +578,7 @@ test_consistent_hashing_distribution() {
     # Upload multiple videos for distribution analysis
     local uploaded_videos=()
     for video in "${TEST_VIDEOS[@]:1:3}"; do
-        local video_id="${video%.mp4}"
+        local video_id=$(video_id_for "$video")
         local existing_code
         existing_code=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/content/$video_id/manifest.mpd" 2>/dev/null || echo "404")
         
@@ -619,7 +635,7 @@ test_index_video_count_accuracy() {
     # Count actual uploaded videos
     local uploaded_count=0
     for video in "${TEST_VIDEOS[@]:0:4}"; do
-        local video_id="${video%.mp4}"
+        local video_id=$(video_id_for "$video")
         local code
         code=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/content/$video_id/manifest.mpd" 2>/dev/null || echo "404")
         if [ "$code" == "200" ]; then
@@ -741,7 +757,7 @@ main() {
     # Verify all uploaded videos are still accessible
     log_info "Verifying all videos remain accessible..."
     for video in "${TEST_VIDEOS[@]:0:4}"; do
-        local video_id="${video%.mp4}"
+        local video_id=$(video_id_for "$video")
         local code
         code=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/content/$video_id/manifest.mpd" 2>/dev/null || echo "404")
         
@@ -765,7 +781,7 @@ main() {
     # Upload remaining videos to test distribution across larger cluster
     log_info "Uploading additional videos for stress testing..."
     for video in "${TEST_VIDEOS[@]:4}"; do
-        local video_id="${video%.mp4}"
+        local video_id=$(video_id_for "$video")
         local existing_code
         existing_code=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/content/$video_id/manifest.mpd" 2>/dev/null || echo "404")
         
@@ -809,7 +825,7 @@ main() {
     # Count total accessible videos
     local total_accessible=0
     for video in "${TEST_VIDEOS[@]}"; do
-        local video_id="${video%.mp4}"
+        local video_id=$(video_id_for "$video")
         local code
         code=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/content/$video_id/manifest.mpd" 2>/dev/null || echo "404")
         if [ "$code" == "200" ]; then
@@ -861,7 +877,7 @@ main() {
         echo ""
         log_info "📹 UPLOADED VIDEOS:"
         for video in "${TEST_VIDEOS[@]}"; do
-            local video_id="${video%.mp4}"
+            local video_id=$(video_id_for "$video")
             local code
             code=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/content/$video_id/manifest.mpd" 2>/dev/null || echo "404")
             if [ "$code" == "200" ]; then
@@ -878,7 +894,7 @@ main() {
         
         log_info "🧪 TEST COVERAGE ACHIEVED:"
         echo "   ✅ All HTTP endpoints (GET /, POST /upload, GET /videos, GET /content)"
-        echo "   ✅ All HTTP status codes (200, 303, 400, 404, 409, 500)"
+        echo "   ✅ All HTTP status codes (200, 303, 400, 404, 500)"
         echo "   ✅ All Content-Type headers (text/html, application/dash+xml, video/mp4)"
         echo "   ✅ All gRPC admin operations (ListNodes, AddNode, RemoveNode)"
         echo "   ✅ File migration tracking and validation"
@@ -302,7 +302,13 @@ func (*DeleteFileResponse) Descriptor() ([]byte, []int) {
 }
 
 type ListFilesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// prefix limits the listing to paths starting with it, such as "videoId/".
+	Prefix string `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// page_size caps the files in one response; 0 returns them all.
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// page_token is the next_page_token of the previous page.
+	PageToken     string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -337,16 +343,110 @@ func (*ListFilesRequest) Descriptor() ([]byte, []int) {
 	return file_proto_storage_proto_rawDescGZIP(), []int{6}
 }
 
+func (x *ListFilesRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *ListFilesRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListFilesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type FileInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Path  string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Size  int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	// mod_time is when the file was last written, in Unix seconds.
+	ModTime int64 `protobuf:"varint,3,opt,name=mod_time,json=modTime,proto3" json:"mod_time,omitempty"`
+	// sha256 is the hex checksum recorded when the file was written.
+	Sha256        string `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileInfo) Reset() {
+	*x = FileInfo{}
+	mi := &file_proto_storage_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileInfo) ProtoMessage() {}
+
+func (x *FileInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileInfo.ProtoReflect.Descriptor instead.
+func (*FileInfo) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FileInfo) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileInfo) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *FileInfo) GetModTime() int64 {
+	if x != nil {
+		return x.ModTime
+	}
+	return 0
+}
+
+func (x *FileInfo) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
 type ListFilesResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Paths         []string               `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Paths []string               `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+	Files []*FileInfo            `protobuf:"bytes,2,rep,name=files,proto3" json:"files,omitempty"`
+	// next_page_token is empty on the last page.
+	NextPageToken string `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListFilesResponse) Reset() {
 	*x = ListFilesResponse{}
-	mi := &file_proto_storage_proto_msgTypes[7]
+	mi := &file_proto_storage_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -358,7 +458,7 @@ func (x *ListFilesResponse) String() string {
 func (*ListFilesResponse) ProtoMessage() {}
 
 func (x *ListFilesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_storage_proto_msgTypes[7]
+	mi := &file_proto_storage_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -371,7 +471,7 @@ func (x *ListFilesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListFilesResponse.ProtoReflect.Descriptor instead.
 func (*ListFilesResponse) Descriptor() ([]byte, []int) {
-	return file_proto_storage_proto_rawDescGZIP(), []int{7}
+	return file_proto_storage_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ListFilesResponse) GetPaths() []string {
@@ -381,6 +481,590 @@ func (x *ListFilesResponse) GetPaths() []string {
 	return nil
 }
 
+func (x *ListFilesResponse) GetFiles() []*FileInfo {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *ListFilesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type CopyToRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VideoId       string                 `protobuf:"bytes,1,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	Filename      string                 `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`
+	Target        string                 `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CopyToRequest) Reset() {
+	*x = CopyToRequest{}
+	mi := &file_proto_storage_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CopyToRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CopyToRequest) ProtoMessage() {}
+
+func (x *CopyToRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CopyToRequest.ProtoReflect.Descriptor instead.
+func (*CopyToRequest) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CopyToRequest) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *CopyToRequest) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *CopyToRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type CopyToResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          int64                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Sha256        string                 `protobuf:"bytes,2,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CopyToResponse) Reset() {
+	*x = CopyToResponse{}
+	mi := &file_proto_storage_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CopyToResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CopyToResponse) ProtoMessage() {}
+
+func (x *CopyToResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CopyToResponse.ProtoReflect.Descriptor instead.
+func (*CopyToResponse) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CopyToResponse) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *CopyToResponse) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+type StatFileRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	VideoId  string                 `protobuf:"bytes,1,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	Filename string                 `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`
+	// verify re-reads the file and fails with DATA_LOSS if it no longer
+	// matches its checksum.
+	Verify        bool `protobuf:"varint,3,opt,name=verify,proto3" json:"verify,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatFileRequest) Reset() {
+	*x = StatFileRequest{}
+	mi := &file_proto_storage_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatFileRequest) ProtoMessage() {}
+
+func (x *StatFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatFileRequest.ProtoReflect.Descriptor instead.
+func (*StatFileRequest) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StatFileRequest) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *StatFileRequest) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *StatFileRequest) GetVerify() bool {
+	if x != nil {
+		return x.Verify
+	}
+	return false
+}
+
+type StatFileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          int64                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Sha256        string                 `protobuf:"bytes,2,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatFileResponse) Reset() {
+	*x = StatFileResponse{}
+	mi := &file_proto_storage_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatFileResponse) ProtoMessage() {}
+
+func (x *StatFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatFileResponse.ProtoReflect.Descriptor instead.
+func (*StatFileResponse) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StatFileResponse) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *StatFileResponse) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+type GetStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	mi := &file_proto_storage_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{13}
+}
+
+type VideoUsage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VideoId       string                 `protobuf:"bytes,1,opt,name=video_id,json=videoId,proto3" json:"video_id,omitempty"`
+	Files         int64                  `protobuf:"varint,2,opt,name=files,proto3" json:"files,omitempty"`
+	Bytes         int64                  `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VideoUsage) Reset() {
+	*x = VideoUsage{}
+	mi := &file_proto_storage_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VideoUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VideoUsage) ProtoMessage() {}
+
+func (x *VideoUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VideoUsage.ProtoReflect.Descriptor instead.
+func (*VideoUsage) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *VideoUsage) GetVideoId() string {
+	if x != nil {
+		return x.VideoId
+	}
+	return ""
+}
+
+func (x *VideoUsage) GetFiles() int64 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+func (x *VideoUsage) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+// RequestCounters count the requests a node has served since it started.
+type RequestCounters struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Reads        int64                  `protobuf:"varint,1,opt,name=reads,proto3" json:"reads,omitempty"`
+	Writes       int64                  `protobuf:"varint,2,opt,name=writes,proto3" json:"writes,omitempty"`
+	Deletes      int64                  `protobuf:"varint,3,opt,name=deletes,proto3" json:"deletes,omitempty"`
+	Lists        int64                  `protobuf:"varint,4,opt,name=lists,proto3" json:"lists,omitempty"`
+	Copies       int64                  `protobuf:"varint,5,opt,name=copies,proto3" json:"copies,omitempty"`
+	BytesRead    int64                  `protobuf:"varint,6,opt,name=bytes_read,json=bytesRead,proto3" json:"bytes_read,omitempty"`
+	BytesWritten int64                  `protobuf:"varint,7,opt,name=bytes_written,json=bytesWritten,proto3" json:"bytes_written,omitempty"`
+	Errors       int64                  `protobuf:"varint,8,opt,name=errors,proto3" json:"errors,omitempty"`
+	// checksum_failures counts reads refused because the file was corrupt.
+	ChecksumFailures int64 `protobuf:"varint,9,opt,name=checksum_failures,json=checksumFailures,proto3" json:"checksum_failures,omitempty"`
+	// deduped_writes counts writes whose contents were already stored.
+	DedupedWrites int64 `protobuf:"varint,10,opt,name=deduped_writes,json=dedupedWrites,proto3" json:"deduped_writes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestCounters) Reset() {
+	*x = RequestCounters{}
+	mi := &file_proto_storage_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestCounters) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestCounters) ProtoMessage() {}
+
+func (x *RequestCounters) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestCounters.ProtoReflect.Descriptor instead.
+func (*RequestCounters) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RequestCounters) GetReads() int64 {
+	if x != nil {
+		return x.Reads
+	}
+	return 0
+}
+
+func (x *RequestCounters) GetWrites() int64 {
+	if x != nil {
+		return x.Writes
+	}
+	return 0
+}
+
+func (x *RequestCounters) GetDeletes() int64 {
+	if x != nil {
+		return x.Deletes
+	}
+	return 0
+}
+
+func (x *RequestCounters) GetLists() int64 {
+	if x != nil {
+		return x.Lists
+	}
+	return 0
+}
+
+func (x *RequestCounters) GetCopies() int64 {
+	if x != nil {
+		return x.Copies
+	}
+	return 0
+}
+
+func (x *RequestCounters) GetBytesRead() int64 {
+	if x != nil {
+		return x.BytesRead
+	}
+	return 0
+}
+
+func (x *RequestCounters) GetBytesWritten() int64 {
+	if x != nil {
+		return x.BytesWritten
+	}
+	return 0
+}
+
+func (x *RequestCounters) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *RequestCounters) GetChecksumFailures() int64 {
+	if x != nil {
+		return x.ChecksumFailures
+	}
+	return 0
+}
+
+func (x *RequestCounters) GetDedupedWrites() int64 {
+	if x != nil {
+		return x.DedupedWrites
+	}
+	return 0
+}
+
+type GetStatsResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	FileCount  int64                  `protobuf:"varint,1,opt,name=file_count,json=fileCount,proto3" json:"file_count,omitempty"`
+	BytesUsed  int64                  `protobuf:"varint,2,opt,name=bytes_used,json=bytesUsed,proto3" json:"bytes_used,omitempty"`
+	FreeBytes  int64                  `protobuf:"varint,3,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"`
+	TotalBytes int64                  `protobuf:"varint,4,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	Videos     []*VideoUsage          `protobuf:"bytes,5,rep,name=videos,proto3" json:"videos,omitempty"`
+	Requests   *RequestCounters       `protobuf:"bytes,6,opt,name=requests,proto3" json:"requests,omitempty"`
+	// corrupt_files are the paths that failed a checksum and need repair.
+	CorruptFiles []string `protobuf:"bytes,7,rep,name=corrupt_files,json=corruptFiles,proto3" json:"corrupt_files,omitempty"`
+	// last_scrub is when the scrubber last finished a pass, in Unix seconds.
+	LastScrub int64 `protobuf:"varint,8,opt,name=last_scrub,json=lastScrub,proto3" json:"last_scrub,omitempty"`
+	// stored_bytes is what the files take on disk once identical contents are
+	// stored once, in unique_files distinct blobs.
+	StoredBytes int64 `protobuf:"varint,9,opt,name=stored_bytes,json=storedBytes,proto3" json:"stored_bytes,omitempty"`
+	UniqueFiles int64 `protobuf:"varint,10,opt,name=unique_files,json=uniqueFiles,proto3" json:"unique_files,omitempty"`
+	// dedup_ratio is bytes_used divided by stored_bytes.
+	DedupRatio    float64 `protobuf:"fixed64,11,opt,name=dedup_ratio,json=dedupRatio,proto3" json:"dedup_ratio,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	mi := &file_proto_storage_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_storage_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_storage_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetStatsResponse) GetFileCount() int64 {
+	if x != nil {
+		return x.FileCount
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetBytesUsed() int64 {
+	if x != nil {
+		return x.BytesUsed
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetFreeBytes() int64 {
+	if x != nil {
+		return x.FreeBytes
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetTotalBytes() int64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetVideos() []*VideoUsage {
+	if x != nil {
+		return x.Videos
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetRequests() *RequestCounters {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetCorruptFiles() []string {
+	if x != nil {
+		return x.CorruptFiles
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetLastScrub() int64 {
+	if x != nil {
+		return x.LastScrub
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetStoredBytes() int64 {
+	if x != nil {
+		return x.StoredBytes
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetUniqueFiles() int64 {
+	if x != nil {
+		return x.UniqueFiles
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetDedupRatio() float64 {
+	if x != nil {
+		return x.DedupRatio
+	}
+	return 0
+}
+
 var File_proto_storage_proto protoreflect.FileDescriptor
 
 const file_proto_storage_proto_rawDesc = "" +
@@ -400,16 +1084,83 @@ const file_proto_storage_proto_rawDesc = "" +
 	"\x11DeleteFileRequest\x12\x19\n" +
 	"\bvideo_id\x18\x01 \x01(\tR\avideoId\x12\x1a\n" +
 	"\bfilename\x18\x02 \x01(\tR\bfilename\"\x14\n" +
-	"\x12DeleteFileResponse\"\x12\n" +
-	"\x10ListFilesRequest\")\n" +
+	"\x12DeleteFileResponse\"f\n" +
+	"\x10ListFilesRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"e\n" +
+	"\bFileInfo\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x19\n" +
+	"\bmod_time\x18\x03 \x01(\x03R\amodTime\x12\x16\n" +
+	"\x06sha256\x18\x04 \x01(\tR\x06sha256\"}\n" +
 	"\x11ListFilesResponse\x12\x14\n" +
-	"\x05paths\x18\x01 \x03(\tR\x05paths2\xbd\x02\n" +
+	"\x05paths\x18\x01 \x03(\tR\x05paths\x12*\n" +
+	"\x05files\x18\x02 \x03(\v2\x14.tritontube.FileInfoR\x05files\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\"^\n" +
+	"\rCopyToRequest\x12\x19\n" +
+	"\bvideo_id\x18\x01 \x01(\tR\avideoId\x12\x1a\n" +
+	"\bfilename\x18\x02 \x01(\tR\bfilename\x12\x16\n" +
+	"\x06target\x18\x03 \x01(\tR\x06target\"<\n" +
+	"\x0eCopyToResponse\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06sha256\x18\x02 \x01(\tR\x06sha256\"`\n" +
+	"\x0fStatFileRequest\x12\x19\n" +
+	"\bvideo_id\x18\x01 \x01(\tR\avideoId\x12\x1a\n" +
+	"\bfilename\x18\x02 \x01(\tR\bfilename\x12\x16\n" +
+	"\x06verify\x18\x03 \x01(\bR\x06verify\">\n" +
+	"\x10StatFileResponse\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06sha256\x18\x02 \x01(\tR\x06sha256\"\x11\n" +
+	"\x0fGetStatsRequest\"S\n" +
+	"\n" +
+	"VideoUsage\x12\x19\n" +
+	"\bvideo_id\x18\x01 \x01(\tR\avideoId\x12\x14\n" +
+	"\x05files\x18\x02 \x01(\x03R\x05files\x12\x14\n" +
+	"\x05bytes\x18\x03 \x01(\x03R\x05bytes\"\xb7\x02\n" +
+	"\x0fRequestCounters\x12\x14\n" +
+	"\x05reads\x18\x01 \x01(\x03R\x05reads\x12\x16\n" +
+	"\x06writes\x18\x02 \x01(\x03R\x06writes\x12\x18\n" +
+	"\adeletes\x18\x03 \x01(\x03R\adeletes\x12\x14\n" +
+	"\x05lists\x18\x04 \x01(\x03R\x05lists\x12\x16\n" +
+	"\x06copies\x18\x05 \x01(\x03R\x06copies\x12\x1d\n" +
+	"\n" +
+	"bytes_read\x18\x06 \x01(\x03R\tbytesRead\x12#\n" +
+	"\rbytes_written\x18\a \x01(\x03R\fbytesWritten\x12\x16\n" +
+	"\x06errors\x18\b \x01(\x03R\x06errors\x12+\n" +
+	"\x11checksum_failures\x18\t \x01(\x03R\x10checksumFailures\x12%\n" +
+	"\x0ededuped_writes\x18\n" +
+	" \x01(\x03R\rdedupedWrites\"\xa4\x03\n" +
+	"\x10GetStatsResponse\x12\x1d\n" +
+	"\n" +
+	"file_count\x18\x01 \x01(\x03R\tfileCount\x12\x1d\n" +
+	"\n" +
+	"bytes_used\x18\x02 \x01(\x03R\tbytesUsed\x12\x1d\n" +
+	"\n" +
+	"free_bytes\x18\x03 \x01(\x03R\tfreeBytes\x12\x1f\n" +
+	"\vtotal_bytes\x18\x04 \x01(\x03R\n" +
+	"totalBytes\x12.\n" +
+	"\x06videos\x18\x05 \x03(\v2\x16.tritontube.VideoUsageR\x06videos\x127\n" +
+	"\brequests\x18\x06 \x01(\v2\x1b.tritontube.RequestCountersR\brequests\x12#\n" +
+	"\rcorrupt_files\x18\a \x03(\tR\fcorruptFiles\x12\x1d\n" +
+	"\n" +
+	"last_scrub\x18\b \x01(\x03R\tlastScrub\x12!\n" +
+	"\fstored_bytes\x18\t \x01(\x03R\vstoredBytes\x12!\n" +
+	"\funique_files\x18\n" +
+	" \x01(\x03R\vuniqueFiles\x12\x1f\n" +
+	"\vdedup_ratio\x18\v \x01(\x01R\n" +
+	"dedupRatio2\xda\x04\n" +
 	"\x13VideoStorageService\x12H\n" +
 	"\tWriteFile\x12\x1c.tritontube.WriteFileRequest\x1a\x1d.tritontube.WriteFileResponse\x12E\n" +
 	"\bReadFile\x12\x1b.tritontube.ReadFileRequest\x1a\x1c.tritontube.ReadFileResponse\x12K\n" +
 	"\n" +
 	"DeleteFile\x12\x1d.tritontube.DeleteFileRequest\x1a\x1e.tritontube.DeleteFileResponse\x12H\n" +
-	"\tListFiles\x12\x1c.tritontube.ListFilesRequest\x1a\x1d.tritontube.ListFilesResponseB\x10Z\x0einternal/protob\x06proto3"
+	"\tListFiles\x12\x1c.tritontube.ListFilesRequest\x1a\x1d.tritontube.ListFilesResponse\x12L\n" +
+	"\vStreamFiles\x12\x1c.tritontube.ListFilesRequest\x1a\x1d.tritontube.ListFilesResponse0\x01\x12?\n" +
+	"\x06CopyTo\x12\x19.tritontube.CopyToRequest\x1a\x1a.tritontube.CopyToResponse\x12E\n" +
+	"\bStatFile\x12\x1b.tritontube.StatFileRequest\x1a\x1c.tritontube.StatFileResponse\x12E\n" +
+	"\bGetStats\x12\x1b.tritontube.GetStatsRequest\x1a\x1c.tritontube.GetStatsResponseB\x10Z\x0einternal/protob\x06proto3"
 
 var (
 	file_proto_storage_proto_rawDescOnce sync.Once
@@ -423,7 +1174,7 @@ func file_proto_storage_proto_rawDescGZIP() []byte {
 	return file_proto_storage_proto_rawDescData
 }
 
-var file_proto_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proto_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_proto_storage_proto_goTypes = []any{
 	(*WriteFileRequest)(nil),   // 0: tritontube.WriteFileRequest
 	(*WriteFileResponse)(nil),  // 1: tritontube.WriteFileResponse
@@ -432,22 +1183,42 @@ var file_proto_storage_proto_goTypes = []any{
 	(*DeleteFileRequest)(nil),  // 4: tritontube.DeleteFileRequest
 	(*DeleteFileResponse)(nil), // 5: tritontube.DeleteFileResponse
 	(*ListFilesRequest)(nil),   // 6: tritontube.ListFilesRequest
-	(*ListFilesResponse)(nil),  // 7: tritontube.ListFilesResponse
+	(*FileInfo)(nil),           // 7: tritontube.FileInfo
+	(*ListFilesResponse)(nil),  // 8: tritontube.ListFilesResponse
+	(*CopyToRequest)(nil),      // 9: tritontube.CopyToRequest
+	(*CopyToResponse)(nil),     // 10: tritontube.CopyToResponse
+	(*StatFileRequest)(nil),    // 11: tritontube.StatFileRequest
+	(*StatFileResponse)(nil),   // 12: tritontube.StatFileResponse
+	(*GetStatsRequest)(nil),    // 13: tritontube.GetStatsRequest
+	(*VideoUsage)(nil),         // 14: tritontube.VideoUsage
+	(*RequestCounters)(nil),    // 15: tritontube.RequestCounters
+	(*GetStatsResponse)(nil),   // 16: tritontube.GetStatsResponse
 }
 var file_proto_storage_proto_depIdxs = []int32{
-	0, // 0: tritontube.VideoStorageService.WriteFile:input_type -> tritontube.WriteFileRequest
-	2, // 1: tritontube.VideoStorageService.ReadFile:input_type -> tritontube.ReadFileRequest
-	4, // 2: tritontube.VideoStorageService.DeleteFile:input_type -> tritontube.DeleteFileRequest
-	6, // 3: tritontube.VideoStorageService.ListFiles:input_type -> tritontube.ListFilesRequest
-	1, // 4: tritontube.VideoStorageService.WriteFile:output_type -> tritontube.WriteFileResponse
-	3, // 5: tritontube.VideoStorageService.ReadFile:output_type -> tritontube.ReadFileResponse
-	5, // 6: tritontube.VideoStorageService.DeleteFile:output_type -> tritontube.DeleteFileResponse
-	7, // 7: tritontube.VideoStorageService.ListFiles:output_type -> tritontube.ListFilesResponse
-	4, // [4:8] is the sub-list for method output_type
-	0, // [0:4] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	7,  // 0: tritontube.ListFilesResponse.files:type_name -> tritontube.FileInfo
+	14, // 1: tritontube.GetStatsResponse.videos:type_name -> tritontube.VideoUsage
+	15, // 2: tritontube.GetStatsResponse.requests:type_name -> tritontube.RequestCounters
+	0,  // 3: tritontube.VideoStorageService.WriteFile:input_type -> tritontube.WriteFileRequest
+	2,  // 4: tritontube.VideoStorageService.ReadFile:input_type -> tritontube.ReadFileRequest
+	4,  // 5: tritontube.VideoStorageService.DeleteFile:input_type -> tritontube.DeleteFileRequest
+	6,  // 6: tritontube.VideoStorageService.ListFiles:input_type -> tritontube.ListFilesRequest
+	6,  // 7: tritontube.VideoStorageService.StreamFiles:input_type -> tritontube.ListFilesRequest
+	9,  // 8: tritontube.VideoStorageService.CopyTo:input_type -> tritontube.CopyToRequest
+	11, // 9: tritontube.VideoStorageService.StatFile:input_type -> tritontube.StatFileRequest
+	13, // 10: tritontube.VideoStorageService.GetStats:input_type -> tritontube.GetStatsRequest
+	1,  // 11: tritontube.VideoStorageService.WriteFile:output_type -> tritontube.WriteFileResponse
+	3,  // 12: tritontube.VideoStorageService.ReadFile:output_type -> tritontube.ReadFileResponse
+	5,  // 13: tritontube.VideoStorageService.DeleteFile:output_type -> tritontube.DeleteFileResponse
+	8,  // 14: tritontube.VideoStorageService.ListFiles:output_type -> tritontube.ListFilesResponse
+	8,  // 15: tritontube.VideoStorageService.StreamFiles:output_type -> tritontube.ListFilesResponse
+	10, // 16: tritontube.VideoStorageService.CopyTo:output_type -> tritontube.CopyToResponse
+	12, // 17: tritontube.VideoStorageService.StatFile:output_type -> tritontube.StatFileResponse
+	16, // 18: tritontube.VideoStorageService.GetStats:output_type -> tritontube.GetStatsResponse
+	11, // [11:19] is the sub-list for method output_type
+	3,  // [3:11] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_proto_storage_proto_init() }
@@ -461,7 +1232,7 @@ func file_proto_storage_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_storage_proto_rawDesc), len(file_proto_storage_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   8,
+			NumMessages:   17,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
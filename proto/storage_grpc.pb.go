@@ -19,10 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	VideoStorageService_WriteFile_FullMethodName  = "/tritontube.VideoStorageService/WriteFile"
-	VideoStorageService_ReadFile_FullMethodName   = "/tritontube.VideoStorageService/ReadFile"
-	VideoStorageService_DeleteFile_FullMethodName = "/tritontube.VideoStorageService/DeleteFile"
-	VideoStorageService_ListFiles_FullMethodName  = "/tritontube.VideoStorageService/ListFiles"
+	VideoStorageService_WriteFile_FullMethodName   = "/tritontube.VideoStorageService/WriteFile"
+	VideoStorageService_ReadFile_FullMethodName    = "/tritontube.VideoStorageService/ReadFile"
+	VideoStorageService_DeleteFile_FullMethodName  = "/tritontube.VideoStorageService/DeleteFile"
+	VideoStorageService_ListFiles_FullMethodName   = "/tritontube.VideoStorageService/ListFiles"
+	VideoStorageService_StreamFiles_FullMethodName = "/tritontube.VideoStorageService/StreamFiles"
+	VideoStorageService_CopyTo_FullMethodName      = "/tritontube.VideoStorageService/CopyTo"
+	VideoStorageService_StatFile_FullMethodName    = "/tritontube.VideoStorageService/StatFile"
+	VideoStorageService_GetStats_FullMethodName    = "/tritontube.VideoStorageService/GetStats"
 )
 
 // VideoStorageServiceClient is the client API for VideoStorageService service.
@@ -33,6 +37,12 @@ type VideoStorageServiceClient interface {
 	ReadFile(ctx context.Context, in *ReadFileRequest, opts ...grpc.CallOption) (*ReadFileResponse, error)
 	DeleteFile(ctx context.Context, in *DeleteFileRequest, opts ...grpc.CallOption) (*DeleteFileResponse, error)
 	ListFiles(ctx context.Context, in *ListFilesRequest, opts ...grpc.CallOption) (*ListFilesResponse, error)
+	// StreamFiles sends every matching file in pages of page_size.
+	StreamFiles(ctx context.Context, in *ListFilesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListFilesResponse], error)
+	// CopyTo sends a file from this node straight to the target node.
+	CopyTo(ctx context.Context, in *CopyToRequest, opts ...grpc.CallOption) (*CopyToResponse, error)
+	StatFile(ctx context.Context, in *StatFileRequest, opts ...grpc.CallOption) (*StatFileResponse, error)
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
 }
 
 type videoStorageServiceClient struct {
@@ -83,6 +93,55 @@ func (c *videoStorageServiceClient) ListFiles(ctx context.Context, in *ListFiles
 	return out, nil
 }
 
+func (c *videoStorageServiceClient) StreamFiles(ctx context.Context, in *ListFilesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListFilesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VideoStorageService_ServiceDesc.Streams[0], VideoStorageService_StreamFiles_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListFilesRequest, ListFilesResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoStorageService_StreamFilesClient = grpc.ServerStreamingClient[ListFilesResponse]
+
+func (c *videoStorageServiceClient) CopyTo(ctx context.Context, in *CopyToRequest, opts ...grpc.CallOption) (*CopyToResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CopyToResponse)
+	err := c.cc.Invoke(ctx, VideoStorageService_CopyTo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoStorageServiceClient) StatFile(ctx context.Context, in *StatFileRequest, opts ...grpc.CallOption) (*StatFileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatFileResponse)
+	err := c.cc.Invoke(ctx, VideoStorageService_StatFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *videoStorageServiceClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, VideoStorageService_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // VideoStorageServiceServer is the server API for VideoStorageService service.
 // All implementations must embed UnimplementedVideoStorageServiceServer
 // for forward compatibility.
@@ -91,6 +150,12 @@ type VideoStorageServiceServer interface {
 	ReadFile(context.Context, *ReadFileRequest) (*ReadFileResponse, error)
 	DeleteFile(context.Context, *DeleteFileRequest) (*DeleteFileResponse, error)
 	ListFiles(context.Context, *ListFilesRequest) (*ListFilesResponse, error)
+	// StreamFiles sends every matching file in pages of page_size.
+	StreamFiles(*ListFilesRequest, grpc.ServerStreamingServer[ListFilesResponse]) error
+	// CopyTo sends a file from this node straight to the target node.
+	CopyTo(context.Context, *CopyToRequest) (*CopyToResponse, error)
+	StatFile(context.Context, *StatFileRequest) (*StatFileResponse, error)
+	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
 	mustEmbedUnimplementedVideoStorageServiceServer()
 }
 
@@ -113,6 +178,18 @@ func (UnimplementedVideoStorageServiceServer) DeleteFile(context.Context, *Delet
 func (UnimplementedVideoStorageServiceServer) ListFiles(context.Context, *ListFilesRequest) (*ListFilesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListFiles not implemented")
 }
+func (UnimplementedVideoStorageServiceServer) StreamFiles(*ListFilesRequest, grpc.ServerStreamingServer[ListFilesResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamFiles not implemented")
+}
+func (UnimplementedVideoStorageServiceServer) CopyTo(context.Context, *CopyToRequest) (*CopyToResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CopyTo not implemented")
+}
+func (UnimplementedVideoStorageServiceServer) StatFile(context.Context, *StatFileRequest) (*StatFileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StatFile not implemented")
+}
+func (UnimplementedVideoStorageServiceServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
 func (UnimplementedVideoStorageServiceServer) mustEmbedUnimplementedVideoStorageServiceServer() {}
 func (UnimplementedVideoStorageServiceServer) testEmbeddedByValue()                             {}
 
@@ -206,6 +283,71 @@ func _VideoStorageService_ListFiles_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VideoStorageService_StreamFiles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListFilesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VideoStorageServiceServer).StreamFiles(m, &grpc.GenericServerStream[ListFilesRequest, ListFilesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VideoStorageService_StreamFilesServer = grpc.ServerStreamingServer[ListFilesResponse]
+
+func _VideoStorageService_CopyTo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CopyToRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoStorageServiceServer).CopyTo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoStorageService_CopyTo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoStorageServiceServer).CopyTo(ctx, req.(*CopyToRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoStorageService_StatFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoStorageServiceServer).StatFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoStorageService_StatFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoStorageServiceServer).StatFile(ctx, req.(*StatFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VideoStorageService_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VideoStorageServiceServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VideoStorageService_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VideoStorageServiceServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // VideoStorageService_ServiceDesc is the grpc.ServiceDesc for VideoStorageService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -229,7 +371,25 @@ var VideoStorageService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListFiles",
 			Handler:    _VideoStorageService_ListFiles_Handler,
 		},
+		{
+			MethodName: "CopyTo",
+			Handler:    _VideoStorageService_CopyTo_Handler,
+		},
+		{
+			MethodName: "StatFile",
+			Handler:    _VideoStorageService_StatFile_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _VideoStorageService_GetStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamFiles",
+			Handler:       _VideoStorageService_StreamFiles_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/storage.proto",
 }